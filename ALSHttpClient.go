@@ -2,87 +2,245 @@ package animatedledstrip
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultScheme = "http"
+	defaultPort   = 8080
 )
 
 type aLSHttpClient struct {
 	IpAddress string
+
+	scheme      string
+	port        int
+	baseURL     string
+	httpClient  *http.Client
+	headers     http.Header
+	retryPolicy *RetryPolicy
+	logger      Logger
+	validate    bool
+
+	animationsMu sync.Mutex
+	animations   map[string]*AnimationInfo
 }
 
-func ALSHttpClient(ipAddress string) *aLSHttpClient {
-	return &aLSHttpClient{IpAddress: ipAddress}
+// ClientOption configures an aLSHttpClient created by ALSHttpClient
+type ClientOption func(*aLSHttpClient)
+
+// WithPort sets the port the server listens on (default 8080)
+func WithPort(port int) ClientOption {
+	return func(c *aLSHttpClient) {
+		c.port = port
+	}
 }
 
-func (c *aLSHttpClient) resolvePath(path string) string {
-	return fmt.Sprintf("http://%s:8080%s", c.IpAddress, path)
+// WithScheme sets the URL scheme used to reach the server (default "http")
+func WithScheme(scheme string) ClientOption {
+	return func(c *aLSHttpClient) {
+		c.scheme = scheme
+	}
 }
 
-func (c *aLSHttpClient) get(path string) ([]byte, error) {
-	resp, err := http.Get(path)
-	if err != nil {
-		return nil, err
-	} else if resp.StatusCode != 200 {
-		return nil, errors.New(fmt.Sprintf("GET to %s failed with %d", c.IpAddress, resp.StatusCode))
+// WithBaseURL sets the full base URL of the server (e.g. "https://proxy.local/als"),
+// overriding the IP address, scheme and port
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *aLSHttpClient) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	} else {
-		return body, nil
+}
+
+// WithHTTPClient sets the *http.Client used for every request,
+// allowing custom timeouts and transports
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *aLSHttpClient) {
+		c.httpClient = httpClient
 	}
 }
 
-func (c *aLSHttpClient) post(path string, body io.Reader) ([]byte, error) {
-	resp, err := http.Post(path, "application/json", body)
-	if err != nil {
-		return nil, err
-	} else if resp.StatusCode != 200 {
-		return nil, errors.New(fmt.Sprintf("POST to %s failed with %d", c.IpAddress, resp.StatusCode))
+// WithHeader adds a header that is sent with every request
+func WithHeader(key string, value string) ClientOption {
+	return func(c *aLSHttpClient) {
+		c.headers.Add(key, value)
 	}
-	log.Print(resp.StatusCode)
-	defer resp.Body.Close()
-	returnBody, err := ioutil.ReadAll(resp.Body)
+}
+
+// WithHeaders adds headers that are sent with every request
+func WithHeaders(headers http.Header) ClientOption {
+	return func(c *aLSHttpClient) {
+		for key, values := range headers {
+			for _, value := range values {
+				c.headers.Add(key, value)
+			}
+		}
+	}
+}
+
+// WithValidation validates animations against the server's supported animations
+// before StartAnimation sends them, and sections against the strip info and
+// existing sections before CreateNewSection sends them, returning a
+// *ValidationError if any violations are found. Warnings are logged and
+// don't stop the request.
+// The supported animations are fetched once and cached, and are refetched
+// when an animation isn't found in the cache. The strip info and sections are
+// fetched for every CreateNewSection.
+func WithValidation() ClientOption {
+	return func(c *aLSHttpClient) {
+		c.validate = true
+	}
+}
+
+func ALSHttpClient(ipAddress string, opts ...ClientOption) *aLSHttpClient {
+	c := &aLSHttpClient{
+		IpAddress:  ipAddress,
+		scheme:     defaultScheme,
+		port:       defaultPort,
+		httpClient: http.DefaultClient,
+		headers:    http.Header{},
+		logger:     noopLogger{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	if c.logger == nil {
+		c.logger = noopLogger{}
+	}
+	return c
+}
+
+func (c *aLSHttpClient) resolvePath(path string) string {
+	if c.baseURL != "" {
+		return c.baseURL + path
+	}
+	return fmt.Sprintf("%s://%s%s", c.scheme, net.JoinHostPort(c.IpAddress, strconv.Itoa(c.port)), path)
+}
+
+// urlPath returns the path of a request URL for logging
+func urlPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
 	if err != nil {
-		return nil, err
-	} else {
-		return returnBody, nil
+		return rawURL
 	}
+	return u.Path
 }
 
-func (c *aLSHttpClient) delete(path string) ([]byte, error) {
-	req, err := http.NewRequest(http.MethodDelete, path, nil)
+func (c *aLSHttpClient) do(ctx context.Context, method string, path string, body []byte, retry bool) ([]byte, error) {
+	attempts := 1
+	if retry {
+		attempts = c.retryPolicy.attempts()
+	}
+	for attempt := 0; ; attempt++ {
+		returnBody, err := c.doOnce(ctx, method, path, body)
+		if err == nil || attempt+1 >= attempts || !c.retryPolicy.retryable(err) {
+			return returnBody, err
+		}
+		delay := c.retryPolicy.backoff(attempt)
+		c.logger.Warn("retrying request", "method", method, "path", urlPath(path), "attempt", attempt+1, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *aLSHttpClient) doOnce(ctx context.Context, method string, path string, body []byte) ([]byte, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, path, bodyReader)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		c.logger.Error("request failed", "method", method, "path", req.URL.Path,
+			"latency", time.Since(start), "error", err)
 		return nil, err
-	} else if resp.StatusCode != 200 {
-		return nil, errors.New(fmt.Sprintf("POST to %s failed with %d", c.IpAddress, resp.StatusCode))
 	}
 	defer resp.Body.Close()
 	returnBody, err := ioutil.ReadAll(resp.Body)
+	latency := time.Since(start)
+	if resp.StatusCode != 200 {
+		c.logger.Warn("request failed", "method", method, "path", req.URL.Path,
+			"status", resp.StatusCode, "latency", latency, "size", len(returnBody))
+		return nil, &APIError{
+			Method:     method,
+			URL:        path,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(returnBody)),
+		}
+	}
 	if err != nil {
+		c.logger.Error("reading response failed", "method", method, "path", req.URL.Path,
+			"status", resp.StatusCode, "latency", latency, "error", err)
 		return nil, err
-	} else {
-		return returnBody, nil
 	}
+	c.logger.Debug("request completed", "method", method, "path", req.URL.Path,
+		"status", resp.StatusCode, "latency", latency, "size", len(returnBody))
+	return returnBody, nil
 }
 
-func (c *aLSHttpClient) GetAnimationInfo(name string) (*animationInfo, error) {
-	info, err := c.get(c.resolvePath(fmt.Sprintf("/animation/%s", name)))
+func (c *aLSHttpClient) decodeResponse(method string, path string, data []byte, v interface{}) error {
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		c.logger.Error("decoding response failed", "method", method, "path", urlPath(path), "size", len(data), "error", err)
+		return &DecodeError{Method: method, URL: path, Body: data, Err: err}
+	}
+	return nil
+}
+
+func (c *aLSHttpClient) get(ctx context.Context, path string) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, path, nil, true)
+}
+
+func (c *aLSHttpClient) post(ctx context.Context, path string, body []byte, idempotent bool) ([]byte, error) {
+	return c.do(ctx, http.MethodPost, path, body, idempotent)
+}
+
+func (c *aLSHttpClient) delete(ctx context.Context, path string) ([]byte, error) {
+	return c.do(ctx, http.MethodDelete, path, nil, true)
+}
+
+func (c *aLSHttpClient) GetAnimationInfo(name string) (*AnimationInfo, error) {
+	return c.GetAnimationInfoContext(context.Background(), name)
+}
+
+func (c *aLSHttpClient) GetAnimationInfoContext(ctx context.Context, name string) (*AnimationInfo, error) {
+	path := c.resolvePath(fmt.Sprintf("/animation/%s", name))
+	info, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	var newInfo animationInfo
-	err = json.Unmarshal(info, &newInfo)
+	var newInfo AnimationInfo
+	err = c.decodeResponse(http.MethodGet, path, info, &newInfo)
 	if err != nil {
 		return nil, err
 	} else {
@@ -91,12 +249,17 @@ func (c *aLSHttpClient) GetAnimationInfo(name string) (*animationInfo, error) {
 }
 
 func (c *aLSHttpClient) GetSupportedAnimationsNames() ([]string, error) {
-	names, err := c.get(c.resolvePath("/animations/names"))
+	return c.GetSupportedAnimationsNamesContext(context.Background())
+}
+
+func (c *aLSHttpClient) GetSupportedAnimationsNamesContext(ctx context.Context) ([]string, error) {
+	path := c.resolvePath("/animations/names")
+	names, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
 	var newNames []string
-	err = json.Unmarshal(names, &newNames)
+	err = c.decodeResponse(http.MethodGet, path, names, &newNames)
 	if err != nil {
 		return nil, err
 	} else {
@@ -104,13 +267,18 @@ func (c *aLSHttpClient) GetSupportedAnimationsNames() ([]string, error) {
 	}
 }
 
-func (c *aLSHttpClient) GetSupportedAnimations() ([]*animationInfo, error) {
-	infos, err := c.get(c.resolvePath("/animations"))
+func (c *aLSHttpClient) GetSupportedAnimations() ([]*AnimationInfo, error) {
+	return c.GetSupportedAnimationsContext(context.Background())
+}
+
+func (c *aLSHttpClient) GetSupportedAnimationsContext(ctx context.Context) ([]*AnimationInfo, error) {
+	path := c.resolvePath("/animations")
+	infos, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	var newInfos []*animationInfo
-	err = json.Unmarshal(infos, &newInfos)
+	var newInfos []*AnimationInfo
+	err = c.decodeResponse(http.MethodGet, path, infos, &newInfos)
 	if err != nil {
 		return nil, err
 	} else {
@@ -118,13 +286,18 @@ func (c *aLSHttpClient) GetSupportedAnimations() ([]*animationInfo, error) {
 	}
 }
 
-func (c *aLSHttpClient) GetSupportedAnimationsMap() (map[string]*animationInfo, error) {
-	infos, err := c.get(c.resolvePath("/animations/map"))
+func (c *aLSHttpClient) GetSupportedAnimationsMap() (map[string]*AnimationInfo, error) {
+	return c.GetSupportedAnimationsMapContext(context.Background())
+}
+
+func (c *aLSHttpClient) GetSupportedAnimationsMapContext(ctx context.Context) (map[string]*AnimationInfo, error) {
+	path := c.resolvePath("/animations/map")
+	infos, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	var newInfos map[string]*animationInfo
-	err = json.Unmarshal(infos, &newInfos)
+	var newInfos map[string]*AnimationInfo
+	err = c.decodeResponse(http.MethodGet, path, infos, &newInfos)
 	if err != nil {
 		return nil, err
 	} else {
@@ -132,13 +305,18 @@ func (c *aLSHttpClient) GetSupportedAnimationsMap() (map[string]*animationInfo,
 	}
 }
 
-func (c *aLSHttpClient) GetRunningAnimations() (map[string]*runningAnimationParams, error) {
-	params, err := c.get(c.resolvePath("/running"))
+func (c *aLSHttpClient) GetRunningAnimations() (map[string]*RunningAnimationParams, error) {
+	return c.GetRunningAnimationsContext(context.Background())
+}
+
+func (c *aLSHttpClient) GetRunningAnimationsContext(ctx context.Context) (map[string]*RunningAnimationParams, error) {
+	path := c.resolvePath("/running")
+	params, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	var newParams map[string]*runningAnimationParams
-	err = json.Unmarshal(params, &newParams)
+	var newParams map[string]*RunningAnimationParams
+	err = c.decodeResponse(http.MethodGet, path, params, &newParams)
 	if err != nil {
 		return nil, err
 	} else {
@@ -147,12 +325,17 @@ func (c *aLSHttpClient) GetRunningAnimations() (map[string]*runningAnimationPara
 }
 
 func (c *aLSHttpClient) GetRunningAnimationsIds() ([]string, error) {
-	names, err := c.get(c.resolvePath("/running/ids"))
+	return c.GetRunningAnimationsIdsContext(context.Background())
+}
+
+func (c *aLSHttpClient) GetRunningAnimationsIdsContext(ctx context.Context) ([]string, error) {
+	path := c.resolvePath("/running/ids")
+	names, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
 	var newNames []string
-	err = json.Unmarshal(names, &newNames)
+	err = c.decodeResponse(http.MethodGet, path, names, &newNames)
 	if err != nil {
 		return nil, err
 	} else {
@@ -160,13 +343,18 @@ func (c *aLSHttpClient) GetRunningAnimationsIds() ([]string, error) {
 	}
 }
 
-func (c *aLSHttpClient) GetRunningAnimationParams(id string) (*runningAnimationParams, error) {
-	param, err := c.get(c.resolvePath(fmt.Sprintf("/running/%s", id)))
+func (c *aLSHttpClient) GetRunningAnimationParams(id string) (*RunningAnimationParams, error) {
+	return c.GetRunningAnimationParamsContext(context.Background(), id)
+}
+
+func (c *aLSHttpClient) GetRunningAnimationParamsContext(ctx context.Context, id string) (*RunningAnimationParams, error) {
+	path := c.resolvePath(fmt.Sprintf("/running/%s", id))
+	param, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	var newParams runningAnimationParams
-	err = json.Unmarshal(param, &newParams)
+	var newParams RunningAnimationParams
+	err = c.decodeResponse(http.MethodGet, path, param, &newParams)
 	if err != nil {
 		return nil, err
 	} else {
@@ -174,13 +362,18 @@ func (c *aLSHttpClient) GetRunningAnimationParams(id string) (*runningAnimationP
 	}
 }
 
-func (c *aLSHttpClient) EndAnimation(id string) (*runningAnimationParams, error) {
-	param, err := c.delete(c.resolvePath(fmt.Sprintf("/running/%s", id)))
+func (c *aLSHttpClient) EndAnimation(id string) (*RunningAnimationParams, error) {
+	return c.EndAnimationContext(context.Background(), id)
+}
+
+func (c *aLSHttpClient) EndAnimationContext(ctx context.Context, id string) (*RunningAnimationParams, error) {
+	path := c.resolvePath(fmt.Sprintf("/running/%s", id))
+	param, err := c.delete(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	var newParams runningAnimationParams
-	err = json.Unmarshal(param, &newParams)
+	var newParams RunningAnimationParams
+	err = c.decodeResponse(http.MethodDelete, path, param, &newParams)
 	if err != nil {
 		return nil, err
 	} else {
@@ -188,17 +381,26 @@ func (c *aLSHttpClient) EndAnimation(id string) (*runningAnimationParams, error)
 	}
 }
 
-func (c *aLSHttpClient) EndAnimationFromParams(params *runningAnimationParams) (*runningAnimationParams, error) {
-	return c.EndAnimation(params.Id)
+func (c *aLSHttpClient) EndAnimationFromParams(params *RunningAnimationParams) (*RunningAnimationParams, error) {
+	return c.EndAnimationFromParamsContext(context.Background(), params)
+}
+
+func (c *aLSHttpClient) EndAnimationFromParamsContext(ctx context.Context, params *RunningAnimationParams) (*RunningAnimationParams, error) {
+	return c.EndAnimationContext(ctx, params.Id)
 }
 
-func (c *aLSHttpClient) GetSections() ([]*section, error) {
-	sects, err := c.get(c.resolvePath("/sections"))
+func (c *aLSHttpClient) GetSections() ([]*Section, error) {
+	return c.GetSectionsContext(context.Background())
+}
+
+func (c *aLSHttpClient) GetSectionsContext(ctx context.Context) ([]*Section, error) {
+	path := c.resolvePath("/sections")
+	sects, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	var newSects []*section
-	err = json.Unmarshal(sects, &newSects)
+	var newSects []*Section
+	err = c.decodeResponse(http.MethodGet, path, sects, &newSects)
 	if err != nil {
 		return nil, err
 	} else {
@@ -206,13 +408,18 @@ func (c *aLSHttpClient) GetSections() ([]*section, error) {
 	}
 }
 
-func (c *aLSHttpClient) GetSectionsMap() (map[string]*section, error) {
-	sects, err := c.get(c.resolvePath("/sections/map"))
+func (c *aLSHttpClient) GetSectionsMap() (map[string]*Section, error) {
+	return c.GetSectionsMapContext(context.Background())
+}
+
+func (c *aLSHttpClient) GetSectionsMapContext(ctx context.Context) (map[string]*Section, error) {
+	path := c.resolvePath("/sections/map")
+	sects, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	var newSects map[string]*section
-	err = json.Unmarshal(sects, &newSects)
+	var newSects map[string]*Section
+	err = c.decodeResponse(http.MethodGet, path, sects, &newSects)
 	if err != nil {
 		return nil, err
 	} else {
@@ -220,13 +427,18 @@ func (c *aLSHttpClient) GetSectionsMap() (map[string]*section, error) {
 	}
 }
 
-func (c *aLSHttpClient) GetSection(name string) (*section, error) {
-	sect, err := c.get(c.resolvePath(fmt.Sprintf("/section/%s", name)))
+func (c *aLSHttpClient) GetSection(name string) (*Section, error) {
+	return c.GetSectionContext(context.Background(), name)
+}
+
+func (c *aLSHttpClient) GetSectionContext(ctx context.Context, name string) (*Section, error) {
+	path := c.resolvePath(fmt.Sprintf("/section/%s", name))
+	sect, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	var newSect section
-	err = json.Unmarshal(sect, &newSect)
+	var newSect Section
+	err = c.decodeResponse(http.MethodGet, path, sect, &newSect)
 	if err != nil {
 		return nil, err
 	} else {
@@ -234,21 +446,36 @@ func (c *aLSHttpClient) GetSection(name string) (*section, error) {
 	}
 }
 
-func (c *aLSHttpClient) GetFullStripSection() (*section, error) {
-	return c.GetSection("fullStrip")
+func (c *aLSHttpClient) GetFullStripSection() (*Section, error) {
+	return c.GetFullStripSectionContext(context.Background())
+}
+
+func (c *aLSHttpClient) GetFullStripSectionContext(ctx context.Context) (*Section, error) {
+	return c.GetSectionContext(ctx, "fullStrip")
+}
+
+func (c *aLSHttpClient) CreateNewSection(newSection *Section) (*Section, error) {
+	return c.CreateNewSectionContext(context.Background(), newSection)
 }
 
-func (c *aLSHttpClient) CreateNewSection(newSection *section) (*section, error) {
+func (c *aLSHttpClient) CreateNewSectionContext(ctx context.Context, newSection *Section) (*Section, error) {
+	if c.validate {
+		err := c.validateSection(ctx, newSection)
+		if err != nil {
+			return nil, err
+		}
+	}
 	body, err := json.Marshal(newSection)
 	if err != nil {
 		return nil, err
 	}
-	sect, err := c.post(c.resolvePath("/sections"), bytes.NewReader(body))
+	path := c.resolvePath("/sections")
+	sect, err := c.post(ctx, path, body, false)
 	if err != nil {
 		return nil, err
 	}
-	var newSect section
-	err = json.Unmarshal(sect, &newSect)
+	var newSect Section
+	err = c.decodeResponse(http.MethodPost, path, sect, &newSect)
 	if err != nil {
 		return nil, err
 	} else {
@@ -256,35 +483,92 @@ func (c *aLSHttpClient) CreateNewSection(newSection *section) (*section, error)
 	}
 }
 
-func (c *aLSHttpClient) StartAnimation(newAnim *animationToRunParams) (*runningAnimationParams, error) {
+func (c *aLSHttpClient) StartAnimation(newAnim *AnimationToRunParams) (*RunningAnimationParams, error) {
+	return c.StartAnimationContext(context.Background(), newAnim)
+}
+
+func (c *aLSHttpClient) StartAnimationContext(ctx context.Context, newAnim *AnimationToRunParams) (*RunningAnimationParams, error) {
+	if c.validate {
+		err := c.validateAnimation(ctx, newAnim)
+		if err != nil {
+			return nil, err
+		}
+	}
 	body, err := json.Marshal(newAnim)
 	if err != nil {
-		log.Print(err.Error())
 		return nil, err
 	}
-	params, err := c.post(c.resolvePath("/start"), bytes.NewReader(body))
+	path := c.resolvePath("/start")
+	idempotent := newAnim.Id != "" && c.retryPolicy != nil && c.retryPolicy.RetryStartAnimationWithId
+	params, err := c.post(ctx, path, body, idempotent)
 	if err != nil {
-		log.Print(err.Error())
 		return nil, err
 	}
-	var newParams runningAnimationParams
-	err = json.Unmarshal(params, &newParams)
+	var newParams RunningAnimationParams
+	err = c.decodeResponse(http.MethodPost, path, params, &newParams)
 	if err != nil {
-		log.Print(err.Error())
-		log.Print(string(params))
 		return nil, err
 	} else {
 		return &newParams, nil
 	}
 }
 
-func (c *aLSHttpClient) GetStripInfo() (*stripInfo, error) {
-	info, err := c.get(c.resolvePath("/strip/info"))
+func (c *aLSHttpClient) validateAnimation(ctx context.Context, newAnim *AnimationToRunParams) error {
+	c.animationsMu.Lock()
+	animations := c.animations
+	c.animationsMu.Unlock()
+	if animations == nil || FindAnimation(animations, newAnim.Animation) == nil {
+		var err error
+		animations, err = c.GetSupportedAnimationsMapContext(ctx)
+		if err != nil {
+			return err
+		}
+		c.animationsMu.Lock()
+		c.animations = animations
+		c.animationsMu.Unlock()
+	}
+	var violations []*Violation
+	for _, v := range newAnim.ValidateAgainst(animations) {
+		if v.Warning {
+			c.logger.Warn("validation warning", "animation", newAnim.Animation, "violation", v.String())
+			continue
+		}
+		violations = append(violations, v)
+	}
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func (c *aLSHttpClient) validateSection(ctx context.Context, newSection *Section) error {
+	stripInfo, err := c.GetStripInfoContext(ctx)
+	if err != nil {
+		return err
+	}
+	sections, err := c.GetSectionsMapContext(ctx)
+	if err != nil {
+		return err
+	}
+	violations := newSection.Validate(stripInfo, sections)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func (c *aLSHttpClient) GetStripInfo() (*StripInfo, error) {
+	return c.GetStripInfoContext(context.Background())
+}
+
+func (c *aLSHttpClient) GetStripInfoContext(ctx context.Context) (*StripInfo, error) {
+	path := c.resolvePath("/strip/info")
+	info, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
-	var newInfo stripInfo
-	err = json.Unmarshal(info, &newInfo)
+	var newInfo StripInfo
+	err = c.decodeResponse(http.MethodGet, path, info, &newInfo)
 	if err != nil {
 		return nil, err
 	} else {
@@ -293,12 +577,17 @@ func (c *aLSHttpClient) GetStripInfo() (*stripInfo, error) {
 }
 
 func (c *aLSHttpClient) GetCurrentStripColor() ([]int, error) {
-	color, err := c.get(c.resolvePath("/strip/color"))
+	return c.GetCurrentStripColorContext(context.Background())
+}
+
+func (c *aLSHttpClient) GetCurrentStripColorContext(ctx context.Context) ([]int, error) {
+	path := c.resolvePath("/strip/color")
+	color, err := c.get(ctx, path)
 	if err != nil {
 		return nil, err
 	}
 	var newColor []int
-	err = json.Unmarshal(color, &newColor)
+	err = c.decodeResponse(http.MethodGet, path, color, &newColor)
 	if err != nil {
 		return nil, err
 	} else {
@@ -307,6 +596,11 @@ func (c *aLSHttpClient) GetCurrentStripColor() ([]int, error) {
 }
 
 func (c *aLSHttpClient) ClearStrip() error {
-	_, err := c.get(c.resolvePath("/strip/clear"))
+	return c.ClearStripContext(context.Background())
+}
+
+func (c *aLSHttpClient) ClearStripContext(ctx context.Context) error {
+	path := c.resolvePath("/strip/clear")
+	_, err := c.get(ctx, path)
 	return err
 }
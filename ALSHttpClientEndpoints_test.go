@@ -0,0 +1,206 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/alstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer() *alstest.Server {
+	return alstest.NewServer(
+		alstest.WithNumLEDs(10),
+		alstest.WithAnimations(
+			&als.AnimationInfo{Name: "Color", Abbr: "COL", RunCountDefault: 1, MinimumColors: 1},
+			&als.AnimationInfo{Name: "Meteor", Abbr: "MET", RunCountDefault: -1, MinimumColors: 1},
+		),
+		alstest.WithSections(als.NewSection("left", []int{0, 1, 2, 3, 4}, "fullStrip")),
+	)
+}
+
+func TestALSHttpClient_AnimationEndpoints(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	c := server.Client()
+
+	info, err := c.GetAnimationInfo("Meteor")
+	assert.Nil(t, err)
+	assert.Equal(t, "MET", info.Abbr)
+
+	_, err = c.GetAnimationInfo("Missing")
+	assert.True(t, errors.Is(err, als.ErrNotFound))
+
+	names, err := c.GetSupportedAnimationsNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Color", "Meteor"}, names)
+
+	infos, err := c.GetSupportedAnimations()
+	assert.Nil(t, err)
+	assert.Len(t, infos, 2)
+
+	infoMap, err := c.GetSupportedAnimationsMap()
+	assert.Nil(t, err)
+	assert.Equal(t, -1, infoMap["Meteor"].RunCountDefault)
+}
+
+func TestALSHttpClient_RunningEndpoints(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	c := server.Client()
+
+	params, err := c.StartAnimation(als.NewAnimationToRunParams("Meteor",
+		[]*als.ColorContainer{als.NewColorContainer([]int{0xFF0000})}, "m", "left", 0,
+		map[string]int{"spacing": 3}, nil, nil,
+		map[string]*als.Location{"center": als.NewLocation(1, 0, 0)},
+		map[string]*als.Distance{"distance": als.PercentDistance(50, 0, 0)},
+		map[string]*als.Rotation{"rotation": als.DegreesRotation(0, 0, 90, []string{"ROTATE_Z"})},
+		map[string]*als.Equation{"eq": als.NewEquation([]float64{1, 2})}))
+	assert.Nil(t, err)
+	assert.Equal(t, "m", params.Id)
+	assert.Equal(t, "Meteor", params.AnimationName)
+	assert.Equal(t, "left", params.Section)
+	assert.Equal(t, -1, params.RunCount)
+	assert.Equal(t, 3, params.IntParams["spacing"])
+	assert.Equal(t, "PercentDistance", params.DistanceParams["distance"].DistanceType)
+	assert.Equal(t, "DegreesRotation", params.RotationParams["rotation"].RotationType)
+	assert.Equal(t, []float64{1, 2}, params.EquationParams["eq"].Coefficients)
+	assert.Equal(t, "Meteor", params.SourceParams.Animation)
+
+	_, err = c.StartAnimation(&als.AnimationToRunParams{Animation: "Missing"})
+	assert.True(t, errors.Is(err, als.ErrBadRequest))
+
+	running, err := c.GetRunningAnimations()
+	assert.Nil(t, err)
+	assert.Contains(t, running, "m")
+
+	ids, err := c.GetRunningAnimationsIds()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"m"}, ids)
+
+	p, err := c.GetRunningAnimationParams("m")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, p.LocationParams["center"].X)
+
+	ended, err := c.EndAnimationFromParams(p)
+	assert.Nil(t, err)
+	assert.Equal(t, "m", ended.Id)
+
+	_, err = c.EndAnimation("m")
+	assert.True(t, errors.Is(err, als.ErrNotFound))
+	_, err = c.GetRunningAnimationParams("m")
+	assert.True(t, errors.Is(err, als.ErrNotFound))
+}
+
+func TestALSHttpClient_SectionEndpoints(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	c := server.Client()
+
+	sects, err := c.GetSections()
+	assert.Nil(t, err)
+	assert.Len(t, sects, 2)
+
+	sectMap, err := c.GetSectionsMap()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, sectMap["left"].Pixels)
+
+	sect, err := c.GetSection("left")
+	assert.Nil(t, err)
+	assert.Equal(t, "fullStrip", sect.ParentSectionName)
+
+	full, err := c.GetFullStripSection()
+	assert.Nil(t, err)
+	assert.Len(t, full.Pixels, 10)
+
+	newSect, err := c.CreateNewSection(als.NewSection("right", []int{5, 6, 7, 8, 9}, "fullStrip"))
+	assert.Nil(t, err)
+	assert.Equal(t, "right", newSect.Name)
+
+	_, err = c.CreateNewSection(als.NewSection("bad", []int{10}, "fullStrip"))
+	assert.True(t, errors.Is(err, als.ErrBadRequest))
+	_, err = c.GetSection("bad")
+	assert.True(t, errors.Is(err, als.ErrNotFound))
+}
+
+func TestALSHttpClient_StripEndpoints(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	c := server.Client()
+
+	info, err := c.GetStripInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, 10, info.NumLEDs)
+
+	server.State.SetStripColor([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+	color, err := c.GetCurrentStripColor()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, color)
+
+	assert.Nil(t, c.ClearStrip())
+	color, _ = c.GetCurrentStripColor()
+	assert.Equal(t, make([]int, 10), color)
+
+	assert.Equal(t, []string{"GET /strip/info", "GET /strip/color", "GET /strip/clear", "GET /strip/color"},
+		server.Requests())
+}
+
+func TestALSHttpClient_InjectedFaults(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	c := server.Client()
+
+	server.InjectError(http.MethodGet, "/strip/info", http.StatusInternalServerError, "boom")
+	_, err := c.GetStripInfo()
+	assert.True(t, errors.Is(err, als.ErrServer))
+	var apiErr *als.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "boom", apiErr.Body)
+
+	server.InjectMalformed("", "/running")
+	_, err = c.GetRunningAnimations()
+	assert.True(t, errors.Is(err, als.ErrDecode))
+
+	server.ClearFaults()
+	_, err = c.GetStripInfo()
+	assert.Nil(t, err)
+
+	server.AddFault(alstest.Fault{Path: "/strip/color", StatusCode: http.StatusServiceUnavailable, Times: 2})
+	policy := als.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	retrying := server.Client(als.WithRetryPolicy(policy))
+	_, err = retrying.GetCurrentStripColor()
+	assert.Nil(t, err)
+
+	server.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = c.GetStripInfoContext(ctx)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,114 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestALSHttpClient_ResolvePathDefault(t *testing.T) {
+	c := ALSHttpClient("10.0.0.254")
+
+	assert.Equal(t, "http://10.0.0.254:8080/strip/info", c.resolvePath("/strip/info"))
+}
+
+func TestALSHttpClient_ResolvePathOptions(t *testing.T) {
+	c := ALSHttpClient("10.0.0.254", WithScheme("https"), WithPort(443))
+	assert.Equal(t, "https://10.0.0.254:443/strip/info", c.resolvePath("/strip/info"))
+
+	c = ALSHttpClient("::1", WithPort(9000))
+	assert.Equal(t, "http://[::1]:9000/strip/info", c.resolvePath("/strip/info"))
+
+	c = ALSHttpClient("10.0.0.254", WithBaseURL("https://proxy.local/als/"))
+	assert.Equal(t, "https://proxy.local/als/strip/info", c.resolvePath("/strip/info"))
+}
+
+func TestALSHttpClient_HonorsOptions(t *testing.T) {
+	var gotHeader, gotContentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("Authorization")
+		gotContentType = r.Header.Get("Content-Type")
+		_, _ = w.Write([]byte(`{"name":"section","pixels":[0,1],"parentSectionName":"fullStrip"}`))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	host, portStr, _ := net.SplitHostPort(u.Host)
+	port, _ := strconv.Atoi(portStr)
+
+	httpClient := &http.Client{Timeout: time.Second}
+	c := ALSHttpClient(host, WithPort(port), WithHTTPClient(httpClient), WithHeader("Authorization", "Bearer token"))
+	assert.Same(t, httpClient, c.httpClient)
+
+	sect, err := c.GetSection("section")
+	assert.Nil(t, err)
+	assert.Equal(t, "section", sect.Name)
+	assert.Equal(t, "Bearer token", gotHeader)
+	assert.Equal(t, "", gotContentType)
+
+	c = ALSHttpClient("", WithBaseURL(server.URL), WithHeaders(http.Header{"Authorization": {"Basic abc"}}))
+	_, err = c.CreateNewSection(NewSection("section", []int{0, 1}, "fullStrip"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Basic abc", gotHeader)
+	assert.Equal(t, "application/json", gotContentType)
+}
+
+func TestALSHttpClient_NilHTTPClient(t *testing.T) {
+	c := ALSHttpClient("10.0.0.254", WithHTTPClient(nil))
+
+	assert.Same(t, http.DefaultClient, c.httpClient)
+}
+
+func TestALSHttpClient_ContextCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	c := ALSHttpClient("", WithBaseURL(server.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.GetStripInfoContext(ctx)
+	assert.NotNil(t, err)
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = c.ClearStripContext(ctx)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,776 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// Prefixes of the messages in the socket protocol.
+// Each message is a 4 character prefix, a colon and a JSON body, terminated by ";;;".
+const (
+	PrefixAnimationInfo          = "AINF"
+	PrefixAnimationToRunParams   = "ANIM"
+	PrefixCommand                = "CMD "
+	PrefixEndAnimation           = "END "
+	PrefixMessage                = "MSG "
+	PrefixRunningAnimationParams = "RUN "
+	PrefixSection                = "SECT"
+	PrefixStripColor             = "SCOL"
+	PrefixStripInfo              = "SINF"
+)
+
+const messageDelimiter = ";;;"
+
+// ErrNotConnected is returned by an aLSSocketClient that is not connected to a server
+var ErrNotConnected = errors.New("not connected")
+
+// SocketCallbacks are called from the read loop of an aLSSocketClient
+// for updates sent by the server; any of them may be nil
+type SocketCallbacks struct {
+	OnConnect          func()
+	OnDisconnect       func(err error)
+	OnStripInfo        func(info *StripInfo)
+	OnAnimationInfo    func(info *AnimationInfo)
+	OnAnimationStarted func(params *RunningAnimationParams)
+	OnAnimationEnded   func(params *RunningAnimationParams)
+	OnSection          func(sect *Section)
+	OnMessage          func(message string)
+}
+
+// SocketOption configures an aLSSocketClient created by ALSSocketClient
+type SocketOption func(*aLSSocketClient)
+
+// WithSocketCallbacks sets the callbacks for updates sent by the server
+func WithSocketCallbacks(callbacks SocketCallbacks) SocketOption {
+	return func(c *aLSSocketClient) {
+		c.callbacks = callbacks
+	}
+}
+
+// WithReconnectDelay sets how long to wait before reconnecting after the connection is lost (default 1s)
+func WithReconnectDelay(delay time.Duration) SocketOption {
+	return func(c *aLSSocketClient) {
+		c.reconnectDelay = delay
+	}
+}
+
+// WithDialer sets the dialer used to connect to the server
+func WithDialer(dialer *net.Dialer) SocketOption {
+	return func(c *aLSSocketClient) {
+		c.dialer = dialer
+	}
+}
+
+// WithSocketLogger sets the logger that connection changes and messages are logged to
+func WithSocketLogger(logger Logger) SocketOption {
+	return func(c *aLSSocketClient) {
+		c.logger = logger
+	}
+}
+
+// aLSSocketClient communicates with older AnimatedLEDStrip servers over the
+// prefixed-JSON socket protocol.
+// The server sends its strip info, supported animations, sections and running animations
+// when a client connects and sends updates as they change,
+// so most getters return the state the client has received.
+type aLSSocketClient struct {
+	address        string
+	dialer         *net.Dialer
+	reconnectDelay time.Duration
+	callbacks      SocketCallbacks
+	logger         Logger
+
+	connected atomic.Bool
+	closed    atomic.Bool
+	cancel    context.CancelFunc
+	done      chan struct{}
+
+	writeMu sync.Mutex
+	conn    net.Conn
+
+	mu         sync.Mutex
+	stripInfo  *StripInfo
+	animations map[string]*AnimationInfo
+	sections   map[string]*Section
+	running    map[string]*RunningAnimationParams
+	waiters    map[string][]chan []byte
+}
+
+var _ Client = (*aLSSocketClient)(nil)
+
+func ALSSocketClient(ipAddress string, port int, opts ...SocketOption) *aLSSocketClient {
+	c := &aLSSocketClient{
+		address:        net.JoinHostPort(ipAddress, strconv.Itoa(port)),
+		dialer:         &net.Dialer{},
+		reconnectDelay: time.Second,
+		logger:         noopLogger{},
+		waiters:        map[string][]chan []byte{},
+	}
+	c.resetState()
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.logger == nil {
+		c.logger = noopLogger{}
+	}
+	return c
+}
+
+func (c *aLSSocketClient) resetState() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stripInfo = nil
+	c.animations = map[string]*AnimationInfo{}
+	c.sections = map[string]*Section{}
+	c.running = map[string]*RunningAnimationParams{}
+}
+
+// Connect connects to the server and waits for its strip info;
+// the rest of the server's state arrives asynchronously.
+// If the connection is lost, the client reconnects until Close is called.
+// A client can only be connected once.
+func (c *aLSSocketClient) Connect(ctx context.Context) error {
+	if c.closed.Load() {
+		return errors.New("client is closed")
+	}
+	stripInfo := c.waitFor(PrefixStripInfo, "")
+	defer c.removeWaiter(PrefixStripInfo, "", stripInfo)
+
+	conn, err := c.dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return err
+	}
+	loopCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.mu.Lock()
+	c.cancel = cancel
+	c.done = done
+	c.mu.Unlock()
+	c.setConn(conn)
+	go c.run(loopCtx, conn, done)
+
+	select {
+	case _, ok := <-stripInfo:
+		if !ok {
+			c.Close()
+			return fmt.Errorf("connection lost before strip info: %w", ErrNotConnected)
+		}
+		return nil
+	case <-ctx.Done():
+		c.Close()
+		return ctx.Err()
+	}
+}
+
+// Close disconnects from the server and stops reconnecting
+func (c *aLSSocketClient) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	c.writeMu.Lock()
+	var err error
+	if c.conn != nil {
+		err = c.conn.Close()
+	}
+	c.writeMu.Unlock()
+	if done != nil {
+		<-done
+	}
+	c.failWaiters()
+	return err
+}
+
+// Connected reports whether the client is currently connected
+func (c *aLSSocketClient) Connected() bool {
+	return c.connected.Load()
+}
+
+func (c *aLSSocketClient) setConn(conn net.Conn) {
+	c.writeMu.Lock()
+	c.conn = conn
+	c.writeMu.Unlock()
+	c.connected.Store(true)
+	c.logger.Debug("connected", "address", c.address)
+	if c.callbacks.OnConnect != nil {
+		c.callbacks.OnConnect()
+	}
+}
+
+// run reads from conn and reconnects when the connection is lost, until ctx
+// is done, when it closes done
+func (c *aLSSocketClient) run(ctx context.Context, conn net.Conn, done chan struct{}) {
+	defer close(done)
+	for {
+		err := c.readLoop(conn)
+		c.connected.Store(false)
+		_ = conn.Close()
+		// Replies to requests sent on this connection will never arrive
+		c.failWaiters()
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("disconnected", "address", c.address, "error", err)
+		if c.callbacks.OnDisconnect != nil {
+			c.callbacks.OnDisconnect(err)
+		}
+		c.resetState()
+		for {
+			if !sleepContext(ctx, c.reconnectDelay) {
+				return
+			}
+			conn, err = c.dialer.DialContext(ctx, "tcp", c.address)
+			if err == nil {
+				break
+			}
+			c.logger.Warn("reconnecting failed", "address", c.address, "error", err)
+		}
+		c.setConn(conn)
+		if ctx.Err() != nil {
+			_ = conn.Close()
+			return
+		}
+	}
+}
+
+func splitMessages(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.Index(data, []byte(messageDelimiter)); i >= 0 {
+		return i + len(messageDelimiter), data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func (c *aLSSocketClient) readLoop(conn net.Conn) error {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	scanner.Split(splitMessages)
+	for scanner.Scan() {
+		c.dispatch(scanner.Bytes())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errors.New("connection closed by server")
+}
+
+// dispatch handles a single message based on its prefix
+func (c *aLSSocketClient) dispatch(message []byte) {
+	if len(message) < 5 || message[4] != ':' {
+		c.logger.Warn("invalid message", "message", string(message))
+		return
+	}
+	prefix := string(message[:4])
+	data := message[5:]
+	var err error
+	switch prefix {
+	case PrefixStripInfo:
+		var info *StripInfo
+		info, err = StripInfoFromJson(string(message))
+		if err == nil {
+			c.mu.Lock()
+			c.stripInfo = info
+			c.mu.Unlock()
+			c.notify(PrefixStripInfo, "", data)
+			if c.callbacks.OnStripInfo != nil {
+				c.callbacks.OnStripInfo(info)
+			}
+		}
+	case PrefixAnimationInfo:
+		var info AnimationInfo
+		err = json.Unmarshal(data, &info)
+		if err == nil {
+			c.mu.Lock()
+			c.animations[info.Name] = &info
+			c.mu.Unlock()
+			if c.callbacks.OnAnimationInfo != nil {
+				c.callbacks.OnAnimationInfo(&info)
+			}
+		}
+	case PrefixRunningAnimationParams:
+		var params RunningAnimationParams
+		err = json.Unmarshal(data, &params)
+		if err == nil {
+			c.mu.Lock()
+			c.running[params.Id] = &params
+			c.mu.Unlock()
+			c.notify(prefix, params.Id, data)
+			if c.callbacks.OnAnimationStarted != nil {
+				c.callbacks.OnAnimationStarted(&params)
+			}
+		}
+	case PrefixEndAnimation:
+		var params RunningAnimationParams
+		err = json.Unmarshal(data, &params)
+		if err == nil {
+			c.mu.Lock()
+			delete(c.running, params.Id)
+			c.mu.Unlock()
+			c.notify(prefix, params.Id, data)
+			if c.callbacks.OnAnimationEnded != nil {
+				c.callbacks.OnAnimationEnded(&params)
+			}
+		}
+	case PrefixSection:
+		var sect Section
+		err = json.Unmarshal(data, &sect)
+		if err == nil {
+			c.mu.Lock()
+			c.sections[sect.Name] = &sect
+			c.mu.Unlock()
+			c.notify(prefix, sect.Name, data)
+			if c.callbacks.OnSection != nil {
+				c.callbacks.OnSection(&sect)
+			}
+		}
+	case PrefixStripColor:
+		c.notify(prefix, "", data)
+	case PrefixMessage:
+		var message string
+		err = json.Unmarshal(data, &message)
+		if err == nil && c.callbacks.OnMessage != nil {
+			c.callbacks.OnMessage(message)
+		}
+	default:
+		c.logger.Debug("unknown message", "prefix", prefix)
+	}
+	if err != nil {
+		c.logger.Error("decoding message failed", "prefix", prefix, "size", len(data), "error", err)
+	}
+}
+
+func waiterKey(prefix string, key string) string {
+	return prefix + ":" + key
+}
+
+// waitFor registers a channel that receives the body of the next message with prefix and key
+func (c *aLSSocketClient) waitFor(prefix string, key string) chan []byte {
+	ch := make(chan []byte, 1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := waiterKey(prefix, key)
+	c.waiters[k] = append(c.waiters[k], ch)
+	return ch
+}
+
+func (c *aLSSocketClient) removeWaiter(prefix string, key string, ch chan []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := waiterKey(prefix, key)
+	waiters := c.waiters[k]
+	for i, waiter := range waiters {
+		if waiter == ch {
+			c.waiters[k] = append(waiters[:i:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(c.waiters[k]) == 0 {
+		delete(c.waiters, k)
+	}
+}
+
+func (c *aLSSocketClient) notify(prefix string, key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := waiterKey(prefix, key)
+	for _, waiter := range c.waiters[k] {
+		waiter <- append([]byte(nil), data...)
+	}
+	delete(c.waiters, k)
+}
+
+// failWaiters closes every registered waiter's channel, failing the requests
+// waiting on them
+func (c *aLSSocketClient) failWaiters() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, waiters := range c.waiters {
+		for _, waiter := range waiters {
+			close(waiter)
+		}
+		delete(c.waiters, k)
+	}
+}
+
+func (c *aLSSocketClient) send(ctx context.Context, prefix string, v interface{}) error {
+	if !c.connected.Load() {
+		return ErrNotConnected
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.conn.SetWriteDeadline(deadline)
+		defer c.conn.SetWriteDeadline(time.Time{})
+	}
+	_, err = fmt.Fprintf(c.conn, "%s:%s%s", prefix, data, messageDelimiter)
+	return err
+}
+
+// request sends a message and waits for the response with respPrefix and key.
+// It fails with ErrNotConnected if the connection is lost or the client is
+// closed before the response arrives.
+func (c *aLSSocketClient) request(ctx context.Context, prefix string, v interface{}, respPrefix string, key string) ([]byte, error) {
+	ch := c.waitFor(respPrefix, key)
+	defer c.removeWaiter(respPrefix, key, ch)
+	err := c.send(ctx, prefix, v)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	done := c.done
+	c.mu.Unlock()
+	select {
+	case data, ok := <-ch:
+		if !ok {
+			return nil, ErrNotConnected
+		}
+		return data, nil
+	case <-done:
+		return nil, ErrNotConnected
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *aLSSocketClient) GetAnimationInfo(name string) (*AnimationInfo, error) {
+	return c.GetAnimationInfoContext(context.Background(), name)
+}
+
+func (c *aLSSocketClient) GetAnimationInfoContext(ctx context.Context, name string) (*AnimationInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, ok := c.animations[name]
+	if !ok {
+		return nil, fmt.Errorf("animation %s: %w", name, ErrNotFound)
+	}
+	return info.Copy(), nil
+}
+
+func (c *aLSSocketClient) GetSupportedAnimationsNames() ([]string, error) {
+	return c.GetSupportedAnimationsNamesContext(context.Background())
+}
+
+func (c *aLSSocketClient) GetSupportedAnimationsNamesContext(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.animations))
+	for name := range c.animations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (c *aLSSocketClient) GetSupportedAnimations() ([]*AnimationInfo, error) {
+	return c.GetSupportedAnimationsContext(context.Background())
+}
+
+func (c *aLSSocketClient) GetSupportedAnimationsContext(ctx context.Context) ([]*AnimationInfo, error) {
+	names, _ := c.GetSupportedAnimationsNamesContext(ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	infos := make([]*AnimationInfo, 0, len(names))
+	for _, name := range names {
+		infos = append(infos, c.animations[name].Copy())
+	}
+	return infos, nil
+}
+
+func (c *aLSSocketClient) GetSupportedAnimationsMap() (map[string]*AnimationInfo, error) {
+	return c.GetSupportedAnimationsMapContext(context.Background())
+}
+
+func (c *aLSSocketClient) GetSupportedAnimationsMapContext(ctx context.Context) (map[string]*AnimationInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	infos := make(map[string]*AnimationInfo, len(c.animations))
+	for name, info := range c.animations {
+		infos[name] = info.Copy()
+	}
+	return infos, nil
+}
+
+func (c *aLSSocketClient) GetRunningAnimations() (map[string]*RunningAnimationParams, error) {
+	return c.GetRunningAnimationsContext(context.Background())
+}
+
+func (c *aLSSocketClient) GetRunningAnimationsContext(ctx context.Context) (map[string]*RunningAnimationParams, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	running := make(map[string]*RunningAnimationParams, len(c.running))
+	for id, params := range c.running {
+		running[id] = params.Copy()
+	}
+	return running, nil
+}
+
+func (c *aLSSocketClient) GetRunningAnimationsIds() ([]string, error) {
+	return c.GetRunningAnimationsIdsContext(context.Background())
+}
+
+func (c *aLSSocketClient) GetRunningAnimationsIdsContext(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]string, 0, len(c.running))
+	for id := range c.running {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (c *aLSSocketClient) GetRunningAnimationParams(id string) (*RunningAnimationParams, error) {
+	return c.GetRunningAnimationParamsContext(context.Background(), id)
+}
+
+func (c *aLSSocketClient) GetRunningAnimationParamsContext(ctx context.Context, id string) (*RunningAnimationParams, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	params, ok := c.running[id]
+	if !ok {
+		return nil, fmt.Errorf("running animation %s: %w", id, ErrNotFound)
+	}
+	return params.Copy(), nil
+}
+
+func (c *aLSSocketClient) EndAnimation(id string) (*RunningAnimationParams, error) {
+	return c.EndAnimationContext(context.Background(), id)
+}
+
+func (c *aLSSocketClient) EndAnimationContext(ctx context.Context, id string) (*RunningAnimationParams, error) {
+	if _, err := c.GetRunningAnimationParamsContext(ctx, id); err != nil {
+		return nil, err
+	}
+	data, err := c.request(ctx, PrefixEndAnimation, map[string]string{"id": id}, PrefixEndAnimation, id)
+	if err != nil {
+		return nil, err
+	}
+	var params RunningAnimationParams
+	err = json.Unmarshal(data, &params)
+	if err != nil {
+		return nil, err
+	} else {
+		return &params, nil
+	}
+}
+
+func (c *aLSSocketClient) EndAnimationFromParams(params *RunningAnimationParams) (*RunningAnimationParams, error) {
+	return c.EndAnimationFromParamsContext(context.Background(), params)
+}
+
+func (c *aLSSocketClient) EndAnimationFromParamsContext(ctx context.Context, params *RunningAnimationParams) (*RunningAnimationParams, error) {
+	return c.EndAnimationContext(ctx, params.Id)
+}
+
+func (c *aLSSocketClient) GetSections() ([]*Section, error) {
+	return c.GetSectionsContext(context.Background())
+}
+
+func (c *aLSSocketClient) GetSectionsContext(ctx context.Context) ([]*Section, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.sections))
+	for name := range c.sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sects := make([]*Section, 0, len(names))
+	for _, name := range names {
+		sects = append(sects, copySection(c.sections[name]))
+	}
+	return sects, nil
+}
+
+func (c *aLSSocketClient) GetSectionsMap() (map[string]*Section, error) {
+	return c.GetSectionsMapContext(context.Background())
+}
+
+func (c *aLSSocketClient) GetSectionsMapContext(ctx context.Context) (map[string]*Section, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sects := make(map[string]*Section, len(c.sections))
+	for name, sect := range c.sections {
+		sects[name] = copySection(sect)
+	}
+	return sects, nil
+}
+
+func (c *aLSSocketClient) GetSection(name string) (*Section, error) {
+	return c.GetSectionContext(context.Background(), name)
+}
+
+func (c *aLSSocketClient) GetSectionContext(ctx context.Context, name string) (*Section, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sect, ok := c.sections[name]
+	if !ok {
+		return nil, fmt.Errorf("section %s: %w", name, ErrNotFound)
+	}
+	return copySection(sect), nil
+}
+
+func (c *aLSSocketClient) GetFullStripSection() (*Section, error) {
+	return c.GetFullStripSectionContext(context.Background())
+}
+
+func (c *aLSSocketClient) GetFullStripSectionContext(ctx context.Context) (*Section, error) {
+	return c.GetSectionContext(ctx, "fullStrip")
+}
+
+func (c *aLSSocketClient) CreateNewSection(newSection *Section) (*Section, error) {
+	return c.CreateNewSectionContext(context.Background(), newSection)
+}
+
+func (c *aLSSocketClient) CreateNewSectionContext(ctx context.Context, newSection *Section) (*Section, error) {
+	data, err := c.request(ctx, PrefixSection, newSection, PrefixSection, newSection.Name)
+	if err != nil {
+		return nil, err
+	}
+	var newSect Section
+	err = json.Unmarshal(data, &newSect)
+	if err != nil {
+		return nil, err
+	} else {
+		return &newSect, nil
+	}
+}
+
+func (c *aLSSocketClient) StartAnimation(newAnim *AnimationToRunParams) (*RunningAnimationParams, error) {
+	return c.StartAnimationContext(context.Background(), newAnim)
+}
+
+// StartAnimationContext sends the animation and waits for the server to report it running.
+// If newAnim has no Id, a random one is sent so the response can be matched.
+func (c *aLSSocketClient) StartAnimationContext(ctx context.Context, newAnim *AnimationToRunParams) (*RunningAnimationParams, error) {
+	anim := *newAnim
+	if anim.Id == "" {
+		id, err := c.newAnimationId()
+		if err != nil {
+			return nil, err
+		}
+		anim.Id = id
+	}
+	data, err := c.request(ctx, PrefixAnimationToRunParams, &anim, PrefixRunningAnimationParams, anim.Id)
+	if err != nil {
+		return nil, err
+	}
+	var newParams RunningAnimationParams
+	err = json.Unmarshal(data, &newParams)
+	if err != nil {
+		return nil, err
+	} else {
+		return &newParams, nil
+	}
+}
+
+// maxAnimationId bounds the ids picked by newAnimationId
+var maxAnimationId = big.NewInt(100000000)
+
+// newAnimationId picks a random id that isn't used by a running animation
+func (c *aLSSocketClient) newAnimationId() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		n, err := rand.Int(rand.Reader, maxAnimationId)
+		if err != nil {
+			return "", err
+		}
+		id := n.String()
+		if _, ok := c.running[id]; !ok {
+			return id, nil
+		}
+	}
+}
+
+func (c *aLSSocketClient) GetStripInfo() (*StripInfo, error) {
+	return c.GetStripInfoContext(context.Background())
+}
+
+func (c *aLSSocketClient) GetStripInfoContext(ctx context.Context) (*StripInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stripInfo == nil {
+		return nil, ErrNotConnected
+	}
+	info := *c.stripInfo
+	return &info, nil
+}
+
+func (c *aLSSocketClient) GetCurrentStripColor() ([]int, error) {
+	return c.GetCurrentStripColorContext(context.Background())
+}
+
+func (c *aLSSocketClient) GetCurrentStripColorContext(ctx context.Context) ([]int, error) {
+	data, err := c.request(ctx, PrefixCommand, "strip color", PrefixStripColor, "")
+	if err != nil {
+		return nil, err
+	}
+	var newColor []int
+	err = json.Unmarshal(data, &newColor)
+	if err != nil {
+		return nil, err
+	} else {
+		return newColor, nil
+	}
+}
+
+func (c *aLSSocketClient) ClearStrip() error {
+	return c.ClearStripContext(context.Background())
+}
+
+func (c *aLSSocketClient) ClearStripContext(ctx context.Context) error {
+	return c.send(ctx, PrefixCommand, "strip clear")
+}
+
+// copySection keeps callers from changing the cached state
+func copySection(sect *Section) *Section {
+	c := *sect
+	c.Pixels = append([]int(nil), sect.Pixels...)
+	return &c
+}
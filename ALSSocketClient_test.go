@@ -0,0 +1,381 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// socketServer speaks just enough of the socket protocol to test aLSSocketClient
+type socketServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	conns    []net.Conn
+	silent   bool
+	received chan string
+}
+
+func newSocketServer(t *testing.T) *socketServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s := &socketServer{listener: listener, received: make(chan string, 16)}
+	go s.accept()
+	return s
+}
+
+func (s *socketServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *socketServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		s.write(conn, `SINF:{"numLEDs":10}`)
+		s.write(conn, `AINF:{"name":"Color","abbr":"COL"}`)
+		s.write(conn, `SECT:{"name":"fullStrip","pixels":[0,1,2,3,4,5,6,7,8,9],"parentSectionName":""}`)
+		s.write(conn, `RUN :{"animationName":"Color","id":"existing","section":"fullStrip",`+
+			`"intParams":{"spacing":3},"sourceParams":{"animation":"Color","intParams":{"spacing":3}}}`)
+		go s.read(conn)
+	}
+}
+
+func (s *socketServer) write(conn net.Conn, message string) {
+	_, _ = fmt.Fprintf(conn, "%s;;;", message)
+}
+
+func (s *socketServer) broadcast(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		s.write(conn, message)
+	}
+}
+
+func (s *socketServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *socketServer) read(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	scanner.Split(splitMessages)
+	for scanner.Scan() {
+		message := scanner.Text()
+		s.received <- message
+		s.mu.Lock()
+		silent := s.silent
+		s.mu.Unlock()
+		if silent {
+			continue
+		}
+		prefix, data := message[:4], message[5:]
+		switch prefix {
+		case PrefixAnimationToRunParams:
+			var params AnimationToRunParams
+			_ = json.Unmarshal([]byte(data), &params)
+			s.write(conn, fmt.Sprintf(`RUN :{"animationName":%q,"id":%q,"section":"fullStrip"}`, params.Animation, params.Id))
+		case PrefixEndAnimation:
+			s.write(conn, fmt.Sprintf(`END :{"animationName":"Color","id":%s}`, strings.TrimSuffix(strings.TrimPrefix(data, `{"id":`), "}")))
+		case PrefixSection:
+			s.write(conn, message)
+		case PrefixCommand:
+			if data == `"strip color"` {
+				s.write(conn, `SCOL:[1,2,3]`)
+			}
+		}
+	}
+}
+
+func (s *socketServer) close() {
+	_ = s.listener.Close()
+	s.dropConnections()
+}
+
+func connectSocketClient(t *testing.T, server *socketServer, opts ...SocketOption) *aLSSocketClient {
+	c := ALSSocketClient("127.0.0.1", server.port(), opts...)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.Nil(t, c.Connect(ctx))
+	// Wait for the rest of the initial state
+	assert.Eventually(t, func() bool {
+		_, err := c.GetRunningAnimationParams("existing")
+		return err == nil
+	}, time.Second, time.Millisecond)
+	return c
+}
+
+func TestSplitMessages(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader(`SINF:{};;;MSG :"a;b";;;SECT:{}`))
+	scanner.Split(splitMessages)
+	var messages []string
+	for scanner.Scan() {
+		messages = append(messages, scanner.Text())
+	}
+	assert.Equal(t, []string{`SINF:{}`, `MSG :"a;b"`, `SECT:{}`}, messages)
+}
+
+func TestALSSocketClient_InitialState(t *testing.T) {
+	server := newSocketServer(t)
+	defer server.close()
+	c := connectSocketClient(t, server)
+	defer c.Close()
+
+	assert.True(t, c.Connected())
+
+	info, err := c.GetStripInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, 10, info.NumLEDs)
+
+	names, _ := c.GetSupportedAnimationsNames()
+	assert.Equal(t, []string{"Color"}, names)
+	anim, err := c.GetAnimationInfo("Color")
+	assert.Nil(t, err)
+	assert.Equal(t, "COL", anim.Abbr)
+	_, err = c.GetAnimationInfo("Missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	full, err := c.GetFullStripSection()
+	assert.Nil(t, err)
+	assert.Len(t, full.Pixels, 10)
+
+	ids, _ := c.GetRunningAnimationsIds()
+	assert.Equal(t, []string{"existing"}, ids)
+}
+
+func TestALSSocketClient_Requests(t *testing.T) {
+	server := newSocketServer(t)
+	defer server.close()
+	c := connectSocketClient(t, server)
+	defer c.Close()
+
+	params, err := c.StartAnimation(&AnimationToRunParams{Animation: "Color", Id: "new"})
+	assert.Nil(t, err)
+	assert.Equal(t, "new", params.Id)
+	assert.Equal(t, `ANIM:`, (<-server.received)[:5])
+
+	params, err = c.StartAnimation(&AnimationToRunParams{Animation: "Color"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", params.Id)
+	<-server.received
+
+	running, _ := c.GetRunningAnimations()
+	assert.Len(t, running, 3)
+
+	ended, err := c.EndAnimation("new")
+	assert.Nil(t, err)
+	assert.Equal(t, "new", ended.Id)
+	assert.Equal(t, `END :{"id":"new"}`, <-server.received)
+	_, err = c.GetRunningAnimationParams("new")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = c.EndAnimation("new")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	sect, err := c.CreateNewSection(NewSection("left", []int{0, 1}, "fullStrip"))
+	assert.Nil(t, err)
+	assert.Equal(t, "left", sect.Name)
+	<-server.received
+	sects, _ := c.GetSections()
+	assert.Len(t, sects, 2)
+
+	color, err := c.GetCurrentStripColor()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, color)
+	assert.Equal(t, `CMD :"strip color"`, <-server.received)
+
+	assert.Nil(t, c.ClearStrip())
+	assert.Equal(t, `CMD :"strip clear"`, <-server.received)
+}
+
+func TestALSSocketClient_NewAnimationIdIsUnused(t *testing.T) {
+	server := newSocketServer(t)
+	defer server.close()
+	c := connectSocketClient(t, server)
+	defer c.Close()
+
+	_, err := c.StartAnimation(&AnimationToRunParams{Animation: "Color", Id: "0"})
+	assert.Nil(t, err)
+
+	defer func(max *big.Int) { maxAnimationId = max }(maxAnimationId)
+	maxAnimationId = big.NewInt(2)
+	for i := 0; i < 20; i++ {
+		id, err := c.newAnimationId()
+		assert.Nil(t, err)
+		assert.Equal(t, "1", id)
+	}
+}
+
+func TestALSSocketClient_CachedStateIsCopied(t *testing.T) {
+	server := newSocketServer(t)
+	defer server.close()
+	c := connectSocketClient(t, server)
+	defer c.Close()
+
+	anim, _ := c.GetAnimationInfo("Color")
+	anim.Abbr = "CHANGED"
+	anims, _ := c.GetSupportedAnimations()
+	anims[0].Name = "Changed"
+	full, _ := c.GetFullStripSection()
+	full.Pixels[0] = 9
+	sects, _ := c.GetSectionsMap()
+	sects["fullStrip"].Name = "changed"
+	params, _ := c.GetRunningAnimationParams("existing")
+	params.Section = "changed"
+	params.IntParams["spacing"] = 5
+	params.SourceParams.IntParams["spacing"] = 5
+	running, _ := c.GetRunningAnimations()
+	running["existing"].IntParams["spacing"] = 6
+	info, _ := c.GetStripInfo()
+	info.NumLEDs = 1
+
+	anim, _ = c.GetAnimationInfo("Color")
+	assert.Equal(t, "COL", anim.Abbr)
+	anims, _ = c.GetSupportedAnimations()
+	assert.Equal(t, "Color", anims[0].Name)
+	full, _ = c.GetFullStripSection()
+	assert.Equal(t, 0, full.Pixels[0])
+	assert.Equal(t, "fullStrip", full.Name)
+	params, _ = c.GetRunningAnimationParams("existing")
+	assert.Equal(t, "fullStrip", params.Section)
+	assert.Equal(t, 3, params.IntParams["spacing"])
+	assert.Equal(t, 3, params.SourceParams.IntParams["spacing"])
+	info, _ = c.GetStripInfo()
+	assert.Equal(t, 10, info.NumLEDs)
+}
+
+func TestALSSocketClient_PendingRequestsFail(t *testing.T) {
+	for name, disconnect := range map[string]func(*socketServer, *aLSSocketClient){
+		"connection lost": func(s *socketServer, c *aLSSocketClient) { s.dropConnections() },
+		"closed":          func(s *socketServer, c *aLSSocketClient) { _ = c.Close() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := newSocketServer(t)
+			defer server.close()
+			c := connectSocketClient(t, server, WithReconnectDelay(time.Hour))
+			defer c.Close()
+			server.mu.Lock()
+			server.silent = true
+			server.mu.Unlock()
+
+			result := make(chan error, 1)
+			go func() {
+				_, err := c.GetCurrentStripColor()
+				result <- err
+			}()
+			<-server.received
+			disconnect(server, c)
+
+			select {
+			case err := <-result:
+				assert.True(t, errors.Is(err, ErrNotConnected))
+			case <-time.After(2 * time.Second):
+				t.Fatal("request did not fail")
+			}
+		})
+	}
+}
+
+func TestALSSocketClient_Callbacks(t *testing.T) {
+	server := newSocketServer(t)
+	defer server.close()
+
+	started := make(chan *RunningAnimationParams, 4)
+	ended := make(chan *RunningAnimationParams, 4)
+	messages := make(chan string, 4)
+	c := connectSocketClient(t, server, WithSocketCallbacks(SocketCallbacks{
+		OnAnimationStarted: func(params *RunningAnimationParams) { started <- params },
+		OnAnimationEnded:   func(params *RunningAnimationParams) { ended <- params },
+		OnMessage:          func(message string) { messages <- message },
+	}))
+	defer c.Close()
+
+	assert.Equal(t, "existing", (<-started).Id)
+
+	server.broadcast(`RUN :{"animationName":"Color","id":"other"}`)
+	assert.Equal(t, "other", (<-started).Id)
+	server.broadcast(`END :{"animationName":"Color","id":"other"}`)
+	assert.Equal(t, "other", (<-ended).Id)
+	server.broadcast(`MSG :"hello"`)
+	assert.Equal(t, "hello", <-messages)
+}
+
+func TestALSSocketClient_Reconnect(t *testing.T) {
+	server := newSocketServer(t)
+	defer server.close()
+
+	disconnected := make(chan error, 1)
+	connected := make(chan struct{}, 2)
+	c := connectSocketClient(t, server,
+		WithReconnectDelay(10*time.Millisecond),
+		WithSocketCallbacks(SocketCallbacks{
+			OnConnect:    func() { connected <- struct{}{} },
+			OnDisconnect: func(err error) { disconnected <- err },
+		}))
+	defer c.Close()
+	<-connected
+
+	server.dropConnections()
+	assert.NotNil(t, <-disconnected)
+
+	select {
+	case <-connected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reconnect")
+	}
+	assert.Eventually(t, func() bool {
+		_, err := c.GetStripInfo()
+		return err == nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestALSSocketClient_NotConnected(t *testing.T) {
+	c := ALSSocketClient("127.0.0.1", 1)
+
+	_, err := c.StartAnimation(&AnimationToRunParams{Animation: "Color"})
+	assert.Equal(t, ErrNotConnected, err)
+	_, err = c.GetStripInfo()
+	assert.Equal(t, ErrNotConnected, err)
+	assert.Nil(t, c.Close())
+}
@@ -0,0 +1,202 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+// AnimationBuilder builds an AnimationToRunParams one field at a time.
+// Parameters that are not set explicitly fall back to the defaults of the
+// AnimationInfo the builder was seeded with, if any.
+type AnimationBuilder struct {
+	params      *AnimationToRunParams
+	info        *AnimationInfo
+	runCountSet bool
+}
+
+// NewAnimation starts building params for the named animation.
+func NewAnimation(animation string) *AnimationBuilder {
+	return &AnimationBuilder{
+		params: &AnimationToRunParams{
+			Animation:      animation,
+			Colors:         []*ColorContainer{},
+			IntParams:      map[string]int{},
+			DoubleParams:   map[string]float64{},
+			StringParams:   map[string]string{},
+			LocationParams: map[string]*Location{},
+			DistanceParams: map[string]*Distance{},
+			RotationParams: map[string]*Rotation{},
+			EquationParams: map[string]*Equation{},
+		},
+	}
+}
+
+// NewAnimationFrom starts building params for the animation described by info,
+// using its advertised defaults for anything left unset.
+func NewAnimationFrom(info *AnimationInfo) *AnimationBuilder {
+	return NewAnimation(info.Name).Defaults(info)
+}
+
+// Defaults seeds the builder with the defaults advertised in info.
+func (b *AnimationBuilder) Defaults(info *AnimationInfo) *AnimationBuilder {
+	b.info = info
+	return b
+}
+
+func (b *AnimationBuilder) Id(id string) *AnimationBuilder {
+	b.params.Id = id
+	return b
+}
+
+// Colors appends color containers to the params.
+func (b *AnimationBuilder) Colors(colors ...*ColorContainer) *AnimationBuilder {
+	b.params.Colors = append(b.params.Colors, colors...)
+	return b
+}
+
+// Color appends a single color container holding the given colors.
+func (b *AnimationBuilder) Color(colors ...int) *AnimationBuilder {
+	return b.Colors(NewColorContainer(colors))
+}
+
+func (b *AnimationBuilder) Section(section string) *AnimationBuilder {
+	b.params.Section = section
+	return b
+}
+
+func (b *AnimationBuilder) RunCount(runCount int) *AnimationBuilder {
+	b.params.RunCount = runCount
+	b.runCountSet = true
+	return b
+}
+
+func (b *AnimationBuilder) Int(name string, value int) *AnimationBuilder {
+	b.params.IntParams[name] = value
+	return b
+}
+
+func (b *AnimationBuilder) Double(name string, value float64) *AnimationBuilder {
+	b.params.DoubleParams[name] = value
+	return b
+}
+
+func (b *AnimationBuilder) String(name string, value string) *AnimationBuilder {
+	b.params.StringParams[name] = value
+	return b
+}
+
+func (b *AnimationBuilder) Location(name string, value *Location) *AnimationBuilder {
+	b.params.LocationParams[name] = value
+	return b
+}
+
+func (b *AnimationBuilder) Distance(name string, value *Distance) *AnimationBuilder {
+	b.params.DistanceParams[name] = value
+	return b
+}
+
+func (b *AnimationBuilder) Rotation(name string, value *Rotation) *AnimationBuilder {
+	b.params.RotationParams[name] = value
+	return b
+}
+
+func (b *AnimationBuilder) Equation(name string, value *Equation) *AnimationBuilder {
+	b.params.EquationParams[name] = value
+	return b
+}
+
+// Build returns the params, filling unset parameters from the seeded
+// AnimationInfo's defaults. The builder can be reused after Build.
+func (b *AnimationBuilder) Build() *AnimationToRunParams {
+	p := *b.params
+	p.Colors = append([]*ColorContainer{}, b.params.Colors...)
+	p.IntParams = map[string]int{}
+	p.DoubleParams = map[string]float64{}
+	p.StringParams = map[string]string{}
+	p.LocationParams = map[string]*Location{}
+	p.DistanceParams = map[string]*Distance{}
+	p.RotationParams = map[string]*Rotation{}
+	p.EquationParams = map[string]*Equation{}
+
+	if b.info != nil {
+		if !b.runCountSet {
+			p.RunCount = b.info.RunCountDefault
+		}
+		for _, param := range b.info.IntParams {
+			if v, ok := param.IntDefault(); ok {
+				p.IntParams[param.Name] = v
+			}
+		}
+		for _, param := range b.info.DoubleParams {
+			if v, ok := param.DoubleDefault(); ok {
+				p.DoubleParams[param.Name] = v
+			}
+		}
+		for _, param := range b.info.StringParams {
+			if v, ok := param.StringDefault(); ok {
+				p.StringParams[param.Name] = v
+			}
+		}
+		for _, param := range b.info.LocationParams {
+			if v, ok := param.LocationDefault(); ok {
+				p.LocationParams[param.Name] = v
+			}
+		}
+		for _, param := range b.info.DistanceParams {
+			if v, ok := param.DistanceDefault(); ok {
+				p.DistanceParams[param.Name] = v
+			}
+		}
+		for _, param := range b.info.RotationParams {
+			if v, ok := param.RotationDefault(); ok {
+				p.RotationParams[param.Name] = v
+			}
+		}
+		for _, param := range b.info.EquationParams {
+			if v, ok := param.EquationDefault(); ok {
+				p.EquationParams[param.Name] = v
+			}
+		}
+	}
+
+	for k, v := range b.params.IntParams {
+		p.IntParams[k] = v
+	}
+	for k, v := range b.params.DoubleParams {
+		p.DoubleParams[k] = v
+	}
+	for k, v := range b.params.StringParams {
+		p.StringParams[k] = v
+	}
+	for k, v := range b.params.LocationParams {
+		p.LocationParams[k] = v
+	}
+	for k, v := range b.params.DistanceParams {
+		p.DistanceParams[k] = v
+	}
+	for k, v := range b.params.RotationParams {
+		p.RotationParams[k] = v
+	}
+	for k, v := range b.params.EquationParams {
+		p.EquationParams[k] = v
+	}
+
+	return &p
+}
@@ -0,0 +1,98 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAnimation(t *testing.T) {
+	p := NewAnimation("Meteor").
+		Id("m1").
+		Color(0xFF0000, 0x00FF00).
+		Section("left").
+		RunCount(3).
+		Int("spacing", 5).
+		Double("speed", 1.5).
+		String("text", "hi").
+		Location("center", NewLocation(1, 2, 3)).
+		Distance("distance", PercentDistance(50, 0, 0)).
+		Rotation("rotation", DegreesRotation(0, 90, 0, []string{"ROTATE_Y"})).
+		Equation("eq", NewEquation([]float64{0, 1})).
+		Build()
+
+	assert.Equal(t, "Meteor", p.Animation)
+	assert.Equal(t, "m1", p.Id)
+	assert.Equal(t, []*ColorContainer{NewColorContainer([]int{0xFF0000, 0x00FF00})}, p.Colors)
+	assert.Equal(t, "left", p.Section)
+	assert.Equal(t, 3, p.RunCount)
+	assert.Equal(t, map[string]int{"spacing": 5}, p.IntParams)
+	assert.Equal(t, map[string]float64{"speed": 1.5}, p.DoubleParams)
+	assert.Equal(t, map[string]string{"text": "hi"}, p.StringParams)
+	assert.Equal(t, NewLocation(1, 2, 3), p.LocationParams["center"])
+	assert.Equal(t, PercentDistance(50, 0, 0), p.DistanceParams["distance"])
+	assert.Equal(t, "DegreesRotation", p.RotationParams["rotation"].RotationType)
+	assert.Equal(t, []float64{0, 1}, p.EquationParams["eq"].Coefficients)
+}
+
+func TestNewAnimation_EmptyMaps(t *testing.T) {
+	p := NewAnimation("Color").Build()
+
+	assert.NotNil(t, p.Colors)
+	assert.NotNil(t, p.IntParams)
+	assert.NotNil(t, p.DoubleParams)
+	assert.NotNil(t, p.StringParams)
+	assert.NotNil(t, p.LocationParams)
+	assert.NotNil(t, p.DistanceParams)
+	assert.NotNil(t, p.RotationParams)
+	assert.NotNil(t, p.EquationParams)
+}
+
+func TestNewAnimationFrom_Defaults(t *testing.T) {
+	var info AnimationInfo
+	err := json.Unmarshal([]byte(`{"name":"Meteor","runCountDefault":-1,
+		"intParams":[{"name":"spacing","default":3},{"name":"length"}],
+		"doubleParams":[{"name":"speed","default":2.5}],
+		"locationParams":[{"name":"center","default":{"x":1,"y":2,"z":3}}],
+		"distanceParams":[{"name":"distance","default":{"type":"PercentDistance","x":100,"y":0,"z":0}}]}`), &info)
+	assert.Nil(t, err)
+
+	b := NewAnimationFrom(&info).Int("spacing", 7)
+	p := b.Build()
+
+	assert.Equal(t, "Meteor", p.Animation)
+	assert.Equal(t, -1, p.RunCount)
+	assert.Equal(t, map[string]int{"spacing": 7}, p.IntParams)
+	assert.Equal(t, map[string]float64{"speed": 2.5}, p.DoubleParams)
+	assert.Equal(t, NewLocation(1, 2, 3), p.LocationParams["center"])
+	assert.Equal(t, PercentDistance(100, 0, 0), p.DistanceParams["distance"])
+
+	// Builder can be reused without affecting earlier results
+	p2 := b.RunCount(2).Int("spacing", 1).Build()
+	assert.Equal(t, 2, p2.RunCount)
+	assert.Equal(t, 1, p2.IntParams["spacing"])
+	assert.Equal(t, 7, p.IntParams["spacing"])
+}
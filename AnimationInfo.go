@@ -22,14 +22,213 @@
 
 package animatedledstrip
 
-// TODO: Figure out better way to handle default
-type animationParameter struct {
-	Name        string       `json:"name"`
-	Description string       `json:"description"`
-	Default     *interface{} `json:"default"`
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// ParameterKind identifies which parameter list of an AnimationInfo an
+// AnimationParameter belongs to, which determines the type of its default
+type ParameterKind string
+
+const (
+	IntParameter      ParameterKind = "int"
+	DoubleParameter   ParameterKind = "double"
+	StringParameter   ParameterKind = "string"
+	LocationParameter ParameterKind = "location"
+	DistanceParameter ParameterKind = "distance"
+	RotationParameter ParameterKind = "rotation"
+	EquationParameter ParameterKind = "equation"
+)
+
+// AnimationParameter describes a parameter an animation accepts.
+//
+// When decoded as part of an AnimationInfo, Default holds a value of the
+// concrete type for its list: int, float64, string, *Location, *Distance,
+// *Rotation or *Equation. It is nil if the server advertised no default, or
+// one that couldn't be decoded, in which case DefaultError reports why.
+type AnimationParameter struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Default     interface{}   `json:"default"`
+	Kind        ParameterKind `json:"-"`
+
+	rawDefault json.RawMessage
+	defaultErr error
+}
+
+func (p *AnimationParameter) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Default     json.RawMessage `json:"default"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	p.Name = raw.Name
+	p.Description = raw.Description
+	p.rawDefault = raw.Default
+	p.Default = nil
+	p.defaultErr = nil
+	if p.hasRawDefault() {
+		return json.Unmarshal(raw.Default, &p.Default)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the parameter, keeping a default that couldn't be
+// decoded as the server sent it
+func (p *AnimationParameter) MarshalJSON() ([]byte, error) {
+	def := p.Default
+	if def == nil && p.hasRawDefault() {
+		def = p.rawDefault
+	}
+	return json.Marshal(&struct {
+		Name        string      `json:"name"`
+		Description string      `json:"description"`
+		Default     interface{} `json:"default"`
+	}{p.Name, p.Description, def})
+}
+
+func (p *AnimationParameter) hasRawDefault() bool {
+	return len(p.rawDefault) > 0 && string(p.rawDefault) != "null"
+}
+
+// decodeDefault replaces the generic default with one of the concrete type for kind
+func (p *AnimationParameter) decodeDefault(kind ParameterKind) error {
+	p.Kind = kind
+	if !p.hasRawDefault() {
+		return nil
+	}
+
+	var err error
+	switch kind {
+	case IntParameter:
+		var f float64
+		if err = json.Unmarshal(p.rawDefault, &f); err == nil {
+			if f != math.Trunc(f) {
+				return fmt.Errorf("default for int parameter %s is not an integer: %v", p.Name, f)
+			}
+			p.Default = int(f)
+		}
+	case DoubleParameter:
+		var f float64
+		if err = json.Unmarshal(p.rawDefault, &f); err == nil {
+			p.Default = f
+		}
+	case StringParameter:
+		var s string
+		if err = json.Unmarshal(p.rawDefault, &s); err == nil {
+			p.Default = s
+		}
+	case LocationParameter:
+		var l Location
+		if err = json.Unmarshal(p.rawDefault, &l); err == nil {
+			p.Default = &l
+		}
+	case DistanceParameter:
+		var d Distance
+		if err = json.Unmarshal(p.rawDefault, &d); err == nil {
+			switch d.DistanceType {
+			case "":
+				d.DistanceType = "AbsoluteDistance"
+			case "AbsoluteDistance", "PercentDistance":
+			default:
+				return fmt.Errorf("default for distance parameter %s has unknown type %q", p.Name, d.DistanceType)
+			}
+			p.Default = &d
+		}
+	case RotationParameter:
+		var r Rotation
+		if err = json.Unmarshal(p.rawDefault, &r); err == nil {
+			switch r.RotationType {
+			case "":
+				r.RotationType = "DegreesRotation"
+			case "DegreesRotation", "RadiansRotation":
+			default:
+				return fmt.Errorf("default for rotation parameter %s has unknown type %q", p.Name, r.RotationType)
+			}
+			p.Default = &r
+		}
+	case EquationParameter:
+		var e Equation
+		if err = json.Unmarshal(p.rawDefault, &e); err == nil {
+			p.Default = &e
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("default for %s parameter %s: %w", kind, p.Name, err)
+	}
+	return nil
+}
+
+// HasDefault reports whether the parameter has a usable default
+func (p *AnimationParameter) HasDefault() bool {
+	return p.defaultValue() != nil
+}
+
+// RawDefault returns the default as the server sent it, or nil if it sent none
+func (p *AnimationParameter) RawDefault() json.RawMessage {
+	if p == nil || !p.hasRawDefault() {
+		return nil
+	}
+	return p.rawDefault
+}
+
+// DefaultError returns why the server's default couldn't be decoded into
+// the parameter's type, or nil if it could
+func (p *AnimationParameter) DefaultError() error {
+	if p == nil {
+		return nil
+	}
+	return p.defaultErr
+}
+
+func (p *AnimationParameter) defaultValue() interface{} {
+	if p == nil {
+		return nil
+	}
+	return p.Default
 }
 
-type animationInfo struct {
+func (p *AnimationParameter) IntDefault() (int, bool) {
+	v, ok := p.defaultValue().(int)
+	return v, ok
+}
+
+func (p *AnimationParameter) DoubleDefault() (float64, bool) {
+	v, ok := p.defaultValue().(float64)
+	return v, ok
+}
+
+func (p *AnimationParameter) StringDefault() (string, bool) {
+	v, ok := p.defaultValue().(string)
+	return v, ok
+}
+
+func (p *AnimationParameter) LocationDefault() (*Location, bool) {
+	v, ok := p.defaultValue().(*Location)
+	return v, ok
+}
+
+func (p *AnimationParameter) DistanceDefault() (*Distance, bool) {
+	v, ok := p.defaultValue().(*Distance)
+	return v, ok
+}
+
+func (p *AnimationParameter) RotationDefault() (*Rotation, bool) {
+	v, ok := p.defaultValue().(*Rotation)
+	return v, ok
+}
+
+func (p *AnimationParameter) EquationDefault() (*Equation, bool) {
+	v, ok := p.defaultValue().(*Equation)
+	return v, ok
+}
+
+type AnimationInfo struct {
 	Name            string                `json:"name"`
 	Abbr            string                `json:"abbr"`
 	Description     string                `json:"description"`
@@ -37,11 +236,91 @@ type animationInfo struct {
 	MinimumColors   int                   `json:"minimumColors"`
 	UnlimitedColors bool                  `json:"unlimitedColors"`
 	Dimensionality  []string              `json:"dimensionality"`
-	IntParams       []*animationParameter `json:"intParams"`
-	DoubleParams    []*animationParameter `json:"doubleParams"`
-	StringParams    []*animationParameter `json:"stringParams"`
-	LocationParams  []*animationParameter `json:"locationParams"`
-	DistanceParams  []*animationParameter `json:"distanceParams"`
-	RotationParams  []*animationParameter `json:"rotationParams"`
-	EquationParams  []*animationParameter `json:"equationParams"`
+	IntParams       []*AnimationParameter `json:"intParams"`
+	DoubleParams    []*AnimationParameter `json:"doubleParams"`
+	StringParams    []*AnimationParameter `json:"stringParams"`
+	LocationParams  []*AnimationParameter `json:"locationParams"`
+	DistanceParams  []*AnimationParameter `json:"distanceParams"`
+	RotationParams  []*AnimationParameter `json:"rotationParams"`
+	EquationParams  []*AnimationParameter `json:"equationParams"`
+}
+
+func (i *AnimationInfo) UnmarshalJSON(data []byte) error {
+	type animationInfo AnimationInfo
+	if err := json.Unmarshal(data, (*animationInfo)(i)); err != nil {
+		return err
+	}
+
+	lists := []struct {
+		kind   ParameterKind
+		params []*AnimationParameter
+	}{
+		{IntParameter, i.IntParams},
+		{DoubleParameter, i.DoubleParams},
+		{StringParameter, i.StringParams},
+		{LocationParameter, i.LocationParams},
+		{DistanceParameter, i.DistanceParams},
+		{RotationParameter, i.RotationParams},
+		{EquationParameter, i.EquationParams},
+	}
+	for _, list := range lists {
+		for _, p := range list.params {
+			if p == nil {
+				continue
+			}
+			// A bad default only affects its own parameter
+			if err := p.decodeDefault(list.kind); err != nil {
+				p.Default = nil
+				p.defaultErr = fmt.Errorf("animation %s: %w", i.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Copy returns a deep copy of the animation info, so it can be changed
+// without affecting the original
+func (i *AnimationInfo) Copy() *AnimationInfo {
+	if i == nil {
+		return nil
+	}
+	c := *i
+	if i.Dimensionality != nil {
+		c.Dimensionality = append([]string{}, i.Dimensionality...)
+	}
+	c.IntParams = copyParameters(i.IntParams)
+	c.DoubleParams = copyParameters(i.DoubleParams)
+	c.StringParams = copyParameters(i.StringParams)
+	c.LocationParams = copyParameters(i.LocationParams)
+	c.DistanceParams = copyParameters(i.DistanceParams)
+	c.RotationParams = copyParameters(i.RotationParams)
+	c.EquationParams = copyParameters(i.EquationParams)
+	return &c
+}
+
+func copyParameters(params []*AnimationParameter) []*AnimationParameter {
+	if params == nil {
+		return nil
+	}
+	copied := make([]*AnimationParameter, len(params))
+	for j, p := range params {
+		if p == nil {
+			continue
+		}
+		c := *p
+		switch v := p.Default.(type) {
+		case *Location:
+			l := *v
+			c.Default = &l
+		case *Distance:
+			d := *v
+			c.Default = &d
+		case *Rotation:
+			c.Default = copyRotation(v)
+		case *Equation:
+			c.Default = copyEquation(v)
+		}
+		copied[j] = &c
+	}
+	return copied
 }
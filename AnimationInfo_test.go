@@ -22,6 +22,171 @@
 
 package animatedledstrip
 
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnimationInfo_TypedDefaults(t *testing.T) {
+	jsonStr := `{"name":"Meteor","abbr":"MET",
+		"intParams":[{"name":"spacing","default":3},{"name":"length","default":null},{"name":"count"}],
+		"doubleParams":[{"name":"speed","default":2}],
+		"stringParams":[{"name":"text","default":"hello"}],
+		"locationParams":[{"name":"center","default":{"x":1.0,"y":2.0,"z":3.0}}],
+		"distanceParams":[{"name":"distance","default":{"type":"PercentDistance","x":100.0,"y":0.0,"z":0.0}},{"name":"plain","default":{"x":5.0,"y":0.0,"z":0.0}}],
+		"rotationParams":[{"name":"rotation","default":{"type":"RadiansRotation","xRotation":0.0,"yRotation":1.5,"zRotation":0.0,"rotationOrder":["ROTATE_Y"]}}],
+		"equationParams":[{"name":"eq","default":{"coefficients":[0.0,1.0]}}]}`
+
+	var info AnimationInfo
+	assert.Nil(t, json.Unmarshal([]byte(jsonStr), &info))
+
+	spacing, ok := info.IntParams[0].IntDefault()
+	assert.True(t, ok)
+	assert.Equal(t, 3, spacing)
+	assert.Equal(t, IntParameter, info.IntParams[0].Kind)
+	assert.False(t, info.IntParams[1].HasDefault())
+	assert.False(t, info.IntParams[2].HasDefault())
+
+	speed, ok := info.DoubleParams[0].DoubleDefault()
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, speed)
+	_, ok = info.DoubleParams[0].IntDefault()
+	assert.False(t, ok)
+
+	text, _ := info.StringParams[0].StringDefault()
+	assert.Equal(t, "hello", text)
+
+	center, _ := info.LocationParams[0].LocationDefault()
+	assert.Equal(t, NewLocation(1, 2, 3), center)
+
+	distance, _ := info.DistanceParams[0].DistanceDefault()
+	assert.Equal(t, PercentDistance(100, 0, 0), distance)
+	plain, _ := info.DistanceParams[1].DistanceDefault()
+	assert.Equal(t, AbsoluteDistance(5, 0, 0), plain)
+
+	rotation, _ := info.RotationParams[0].RotationDefault()
+	assert.Equal(t, RadiansRotation(0, 1.5, 0, []string{"ROTATE_Y"}), rotation)
+
+	eq, _ := info.EquationParams[0].EquationDefault()
+	assert.Equal(t, NewEquation([]float64{0, 1}), eq)
+}
+
+func TestAnimationInfo_TypedDefaultsRoundTrip(t *testing.T) {
+	jsonStr := `{"name":"Meteor","intParams":[{"name":"spacing","default":3}],"locationParams":[{"name":"center","default":{"x":1,"y":2,"z":3}}]}`
+
+	var info AnimationInfo
+	assert.Nil(t, json.Unmarshal([]byte(jsonStr), &info))
+	data, err := json.Marshal(&info)
+	assert.Nil(t, err)
+
+	var again AnimationInfo
+	assert.Nil(t, json.Unmarshal(data, &again))
+	assert.Equal(t, info, again)
+}
+
+func TestAnimationInfo_BadDefaults(t *testing.T) {
+	jsonStr := `{"name":"Meteor",
+		"intParams":[{"name":"spacing","default":1.5},{"name":"length","default":4}],
+		"locationParams":[{"name":"center","default":"here"}],
+		"distanceParams":[{"name":"d","default":{"type":"MilesDistance"}},{"name":"e","default":{"x":1.0,"y":0.0,"z":0.0}}]}`
+
+	var info AnimationInfo
+	assert.Nil(t, json.Unmarshal([]byte(jsonStr), &info))
+
+	// Bad defaults are reported on their own parameter
+	spacing := info.IntParams[0]
+	_, ok := spacing.IntDefault()
+	assert.False(t, ok)
+	assert.False(t, spacing.HasDefault())
+	assert.NotNil(t, spacing.DefaultError())
+	assert.Equal(t, json.RawMessage(`1.5`), spacing.RawDefault())
+	_, ok = info.LocationParams[0].LocationDefault()
+	assert.False(t, ok)
+	assert.NotNil(t, info.LocationParams[0].DefaultError())
+	_, ok = info.DistanceParams[0].DistanceDefault()
+	assert.False(t, ok)
+	assert.NotNil(t, info.DistanceParams[0].DefaultError())
+
+	// while the good ones next to them still decode
+	length, ok := info.IntParams[1].IntDefault()
+	assert.True(t, ok)
+	assert.Equal(t, 4, length)
+	assert.Nil(t, info.IntParams[1].DefaultError())
+	distance, ok := info.DistanceParams[1].DistanceDefault()
+	assert.True(t, ok)
+	assert.Equal(t, AbsoluteDistance(1, 0, 0), distance)
+
+	// and bad defaults are encoded again as the server sent them
+	data, err := json.Marshal(info.IntParams[0])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"name":"spacing","description":"","default":1.5}`, string(data))
+}
+
+func TestAnimationParameter_NoDefault(t *testing.T) {
+	var p AnimationParameter
+	assert.Nil(t, json.Unmarshal([]byte(`{"name":"spacing","default":null}`), &p))
+	assert.Nil(t, p.RawDefault())
+	assert.Nil(t, p.DefaultError())
+}
+
+func TestAnimationInfo_Copy(t *testing.T) {
+	info := &AnimationInfo{
+		Name:           "Meteor",
+		Dimensionality: []string{"ONE_DIMENSIONAL"},
+		LocationParams: []*AnimationParameter{{Name: "center", Default: NewLocation(1, 2, 3), Kind: LocationParameter}},
+		RotationParams: []*AnimationParameter{{Name: "rotation", Default: DegreesRotation(0, 0, 90, []string{"ROTATE_Z"}), Kind: RotationParameter}},
+		EquationParams: []*AnimationParameter{{Name: "eq", Default: NewEquation([]float64{0, 1}), Kind: EquationParameter}},
+	}
+	c := info.Copy()
+	assert.Equal(t, info, c)
+
+	c.Dimensionality[0] = "TWO_DIMENSIONAL"
+	center, _ := c.LocationParams[0].LocationDefault()
+	center.X = 10
+	rotation, _ := c.RotationParams[0].RotationDefault()
+	rotation.RotationOrder[0] = "ROTATE_X"
+	eq, _ := c.EquationParams[0].EquationDefault()
+	eq.Coefficients[1] = 2
+
+	assert.Equal(t, []string{"ONE_DIMENSIONAL"}, info.Dimensionality)
+	center, _ = info.LocationParams[0].LocationDefault()
+	assert.Equal(t, NewLocation(1, 2, 3), center)
+	rotation, _ = info.RotationParams[0].RotationDefault()
+	assert.Equal(t, []string{"ROTATE_Z"}, rotation.RotationOrder)
+	eq, _ = info.EquationParams[0].EquationDefault()
+	assert.Equal(t, []float64{0, 1}, eq.Coefficients)
+
+	assert.Nil(t, (*AnimationInfo)(nil).Copy())
+}
+
+func TestAnimationParameter_NilAccessors(t *testing.T) {
+	var p *AnimationParameter
+	assert.False(t, p.HasDefault())
+	_, ok := p.LocationDefault()
+	assert.False(t, ok)
+}
+
+func TestAnimationInfo_BadDefaultsFromClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Meteor":{"name":"Meteor","intParams":[{"name":"spacing","default":"three"},{"name":"length","default":4}]},
+			"Color":{"name":"Color"}}`))
+	}))
+	defer server.Close()
+	c := ALSHttpClient("", WithBaseURL(server.URL))
+
+	animations, err := c.GetSupportedAnimationsMap()
+	assert.Nil(t, err)
+	assert.Len(t, animations, 2)
+	meteor := animations["Meteor"]
+	assert.NotNil(t, meteor.IntParams[0].DefaultError())
+	length, _ := meteor.IntParams[1].IntDefault()
+	assert.Equal(t, 4, length)
+}
+
 //func TestAnimationInfo_FromGoodJson(t *testing.T) {
 //	jsonStr := `AINF:{"name":"Alternate","abbr":"ALT","description":"A description","signatureFile":"alternate.png","repetitive":true,"minimumColors":2,"unlimitedColors":true,"center":"NOTUSED","delay":"USED","direction":"NOTUSED","distance":"NOTUSED","spacing":"NOTUSED","delayDefault":1000,"distanceDefault":20,"spacingDefault":3}`
 //
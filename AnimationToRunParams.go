@@ -1,25 +1,25 @@
 package animatedledstrip
 
-type animationToRunParams struct {
+type AnimationToRunParams struct {
 	Animation      string               `json:"animation"`
-	Colors         []*colorContainer    `json:"colors"`
+	Colors         []*ColorContainer    `json:"colors"`
 	Id             string               `json:"id"`
 	Section        string               `json:"section"`
 	RunCount       int                  `json:"runCount"`
 	IntParams      map[string]int       `json:"intParams"`
 	DoubleParams   map[string]float64   `json:"doubleParams"`
 	StringParams   map[string]string    `json:"stringParams"`
-	LocationParams map[string]*location `json:"locationParams"`
-	DistanceParams map[string]*distance `json:"distanceParams"`
-	RotationParams map[string]*rotation `json:"rotationParams"`
-	EquationParams map[string]*equation `json:"equationParams"`
+	LocationParams map[string]*Location `json:"locationParams"`
+	DistanceParams map[string]*Distance `json:"distanceParams"`
+	RotationParams map[string]*Rotation `json:"rotationParams"`
+	EquationParams map[string]*Equation `json:"equationParams"`
 }
 
-func AnimationToRunParams(animation string, colors []*colorContainer, id string, section string,
+func NewAnimationToRunParams(animation string, colors []*ColorContainer, id string, section string,
 	runCount int, intParams map[string]int, doubleParams map[string]float64, stringParams map[string]string,
-	locationParams map[string]*location, distanceParams map[string]*distance, rotationParams map[string]*rotation,
-	equationParams map[string]*equation) *animationToRunParams {
-	return &animationToRunParams{
+	locationParams map[string]*Location, distanceParams map[string]*Distance, rotationParams map[string]*Rotation,
+	equationParams map[string]*Equation) *AnimationToRunParams {
+	return &AnimationToRunParams{
 		Animation:      animation,
 		Colors:         colors,
 		Id:             id,
@@ -34,3 +34,145 @@ func AnimationToRunParams(animation string, colors []*colorContainer, id string,
 		EquationParams: equationParams,
 	}
 }
+
+// Copy returns a deep copy of the params, so they can be changed without
+// affecting the original
+func (p *AnimationToRunParams) Copy() *AnimationToRunParams {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	if p.Colors != nil {
+		c.Colors = make([]*ColorContainer, len(p.Colors))
+		for i, cc := range p.Colors {
+			if cc == nil {
+				continue
+			}
+			cp := *cc
+			cp.Colors = copyInts(cc.Colors)
+			c.Colors[i] = &cp
+		}
+	}
+	c.IntParams = copyIntParams(p.IntParams)
+	c.DoubleParams = copyDoubleParams(p.DoubleParams)
+	c.StringParams = copyStringParams(p.StringParams)
+	c.LocationParams = copyLocationParams(p.LocationParams)
+	c.DistanceParams = copyDistanceParams(p.DistanceParams)
+	c.RotationParams = copyRotationParams(p.RotationParams)
+	c.EquationParams = copyEquationParams(p.EquationParams)
+	return &c
+}
+
+func copyInts(values []int) []int {
+	if values == nil {
+		return nil
+	}
+	return append([]int{}, values...)
+}
+
+func copyIntParams(params map[string]int) map[string]int {
+	if params == nil {
+		return nil
+	}
+	c := make(map[string]int, len(params))
+	for k, v := range params {
+		c[k] = v
+	}
+	return c
+}
+
+func copyDoubleParams(params map[string]float64) map[string]float64 {
+	if params == nil {
+		return nil
+	}
+	c := make(map[string]float64, len(params))
+	for k, v := range params {
+		c[k] = v
+	}
+	return c
+}
+
+func copyStringParams(params map[string]string) map[string]string {
+	if params == nil {
+		return nil
+	}
+	c := make(map[string]string, len(params))
+	for k, v := range params {
+		c[k] = v
+	}
+	return c
+}
+
+func copyLocationParams(params map[string]*Location) map[string]*Location {
+	if params == nil {
+		return nil
+	}
+	c := make(map[string]*Location, len(params))
+	for k, v := range params {
+		if v != nil {
+			l := *v
+			v = &l
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func copyDistanceParams(params map[string]*Distance) map[string]*Distance {
+	if params == nil {
+		return nil
+	}
+	c := make(map[string]*Distance, len(params))
+	for k, v := range params {
+		if v != nil {
+			d := *v
+			v = &d
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func copyRotationParams(params map[string]*Rotation) map[string]*Rotation {
+	if params == nil {
+		return nil
+	}
+	c := make(map[string]*Rotation, len(params))
+	for k, v := range params {
+		c[k] = copyRotation(v)
+	}
+	return c
+}
+
+func copyEquationParams(params map[string]*Equation) map[string]*Equation {
+	if params == nil {
+		return nil
+	}
+	c := make(map[string]*Equation, len(params))
+	for k, v := range params {
+		c[k] = copyEquation(v)
+	}
+	return c
+}
+
+func copyRotation(r *Rotation) *Rotation {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	if r.RotationOrder != nil {
+		c.RotationOrder = append([]string{}, r.RotationOrder...)
+	}
+	return &c
+}
+
+func copyEquation(e *Equation) *Equation {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	if e.Coefficients != nil {
+		c.Coefficients = append([]float64{}, e.Coefficients...)
+	}
+	return &c
+}
@@ -3,6 +3,8 @@ package animatedledstrip
 import (
 	"encoding/json"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAnimationToRunParams(t *testing.T) {
@@ -21,11 +23,11 @@ func TestAnimationToRunParams(t *testing.T) {
 	//println(ns.Name)
 	//n, _ := c.GetSections()
 	//println(n[7].Name)
-	var col []*colorContainer
+	var col []*ColorContainer
 	var colInts []int
 	colInts = append(colInts, 0xFF)
-	col = append(col, ColorContainer(colInts))
-	a := AnimationToRunParams("ripple", col, "", "", 5, map[string]int{}, map[string]float64{}, map[string]string{}, map[string]*location{}, map[string]*distance{}, map[string]*rotation{}, map[string]*equation{})
+	col = append(col, NewColorContainer(colInts))
+	a := NewAnimationToRunParams("ripple", col, "", "", 5, map[string]int{}, map[string]float64{}, map[string]string{}, map[string]*Location{}, map[string]*Distance{}, map[string]*Rotation{}, map[string]*Equation{})
 	b, e := json.Marshal(a)
 	if e != nil {
 		println(e.Error())
@@ -44,3 +46,54 @@ func TestAnimationToRunParams(t *testing.T) {
 	//println(p.Colors[0].Colors[0])
 	//println(p.RotationParams["rotation"].RotationType)
 }
+
+func TestAnimationToRunParams_Copy(t *testing.T) {
+	assert.Nil(t, (*AnimationToRunParams)(nil).Copy())
+
+	p := NewAnimationToRunParams("Meteor", []*ColorContainer{NewColorContainer([]int{0xFF}), nil}, "a", "s", 1,
+		map[string]int{"i": 1}, map[string]float64{"d": 1}, map[string]string{"s": "x"},
+		map[string]*Location{"l": NewLocation(1, 2, 3)}, map[string]*Distance{"dist": AbsoluteDistance(1, 2, 3)},
+		map[string]*Rotation{"r": DegreesRotation(1, 2, 3, []string{"X"})},
+		map[string]*Equation{"e": NewEquation([]float64{1, 2})})
+	c := p.Copy()
+	assert.Equal(t, p, c)
+
+	c.Colors[0].Colors[0] = 0
+	c.IntParams["i"] = 2
+	c.DoubleParams["d"] = 2
+	c.StringParams["s"] = "y"
+	c.LocationParams["l"].X = 5
+	c.DistanceParams["dist"].X = 5
+	c.RotationParams["r"].RotationOrder[0] = "Y"
+	c.EquationParams["e"].Coefficients[0] = 5
+
+	assert.Equal(t, []int{0xFF}, p.Colors[0].Colors)
+	assert.Equal(t, 1, p.IntParams["i"])
+	assert.Equal(t, 1.0, p.DoubleParams["d"])
+	assert.Equal(t, "x", p.StringParams["s"])
+	assert.Equal(t, 1.0, p.LocationParams["l"].X)
+	assert.Equal(t, 1.0, p.DistanceParams["dist"].X)
+	assert.Equal(t, []string{"X"}, p.RotationParams["r"].RotationOrder)
+	assert.Equal(t, []float64{1, 2}, p.EquationParams["e"].Coefficients)
+}
+
+func TestRunningAnimationParams_Copy(t *testing.T) {
+	assert.Nil(t, (*RunningAnimationParams)(nil).Copy())
+
+	source := NewAnimationToRunParams("Meteor", nil, "a", "s", 1, map[string]int{"i": 1},
+		nil, nil, nil, nil, nil, nil)
+	p := NewRunningAnimationParams("Meteor", []*PreparedColorContainer{NewPreparedColorContainer([]int{1, 2}, []int{1})},
+		"a", "s", 1, map[string]int{"i": 1}, nil, nil, nil, nil, nil, nil, source)
+	c := p.Copy()
+	assert.Equal(t, p, c)
+
+	c.Colors[0].Colors[0] = 0
+	c.Colors[0].OriginalColors[0] = 0
+	c.IntParams["i"] = 2
+	c.SourceParams.IntParams["i"] = 2
+
+	assert.Equal(t, []int{1, 2}, p.Colors[0].Colors)
+	assert.Equal(t, []int{1}, p.Colors[0].OriginalColors)
+	assert.Equal(t, 1, p.IntParams["i"])
+	assert.Equal(t, 1, source.IntParams["i"])
+}
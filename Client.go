@@ -0,0 +1,69 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import "context"
+
+// Client covers every endpoint of an AnimatedLEDStrip server.
+// It is implemented by the client returned by ALSHttpClient
+// and by the in-memory client in the fake package.
+type Client interface {
+	GetAnimationInfo(name string) (*AnimationInfo, error)
+	GetAnimationInfoContext(ctx context.Context, name string) (*AnimationInfo, error)
+	GetSupportedAnimationsNames() ([]string, error)
+	GetSupportedAnimationsNamesContext(ctx context.Context) ([]string, error)
+	GetSupportedAnimations() ([]*AnimationInfo, error)
+	GetSupportedAnimationsContext(ctx context.Context) ([]*AnimationInfo, error)
+	GetSupportedAnimationsMap() (map[string]*AnimationInfo, error)
+	GetSupportedAnimationsMapContext(ctx context.Context) (map[string]*AnimationInfo, error)
+	GetRunningAnimations() (map[string]*RunningAnimationParams, error)
+	GetRunningAnimationsContext(ctx context.Context) (map[string]*RunningAnimationParams, error)
+	GetRunningAnimationsIds() ([]string, error)
+	GetRunningAnimationsIdsContext(ctx context.Context) ([]string, error)
+	GetRunningAnimationParams(id string) (*RunningAnimationParams, error)
+	GetRunningAnimationParamsContext(ctx context.Context, id string) (*RunningAnimationParams, error)
+	EndAnimation(id string) (*RunningAnimationParams, error)
+	EndAnimationContext(ctx context.Context, id string) (*RunningAnimationParams, error)
+	EndAnimationFromParams(params *RunningAnimationParams) (*RunningAnimationParams, error)
+	EndAnimationFromParamsContext(ctx context.Context, params *RunningAnimationParams) (*RunningAnimationParams, error)
+	GetSections() ([]*Section, error)
+	GetSectionsContext(ctx context.Context) ([]*Section, error)
+	GetSectionsMap() (map[string]*Section, error)
+	GetSectionsMapContext(ctx context.Context) (map[string]*Section, error)
+	GetSection(name string) (*Section, error)
+	GetSectionContext(ctx context.Context, name string) (*Section, error)
+	GetFullStripSection() (*Section, error)
+	GetFullStripSectionContext(ctx context.Context) (*Section, error)
+	CreateNewSection(newSection *Section) (*Section, error)
+	CreateNewSectionContext(ctx context.Context, newSection *Section) (*Section, error)
+	StartAnimation(newAnim *AnimationToRunParams) (*RunningAnimationParams, error)
+	StartAnimationContext(ctx context.Context, newAnim *AnimationToRunParams) (*RunningAnimationParams, error)
+	GetStripInfo() (*StripInfo, error)
+	GetStripInfoContext(ctx context.Context) (*StripInfo, error)
+	GetCurrentStripColor() ([]int, error)
+	GetCurrentStripColorContext(ctx context.Context) ([]int, error)
+	ClearStrip() error
+	ClearStripContext(ctx context.Context) error
+}
+
+var _ Client = (*aLSHttpClient)(nil)
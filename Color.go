@@ -0,0 +1,369 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidColor is returned when a color string cannot be parsed
+var ErrInvalidColor = errors.New("invalid color")
+
+// RGB packs red, green and blue components (0-255) into the integer format
+// used by ColorContainer. Components outside the range are clamped.
+func RGB(r, g, b int) int {
+	return clampByte(r)<<16 | clampByte(g)<<8 | clampByte(b)
+}
+
+// SplitRGB unpacks a color into its red, green and blue components
+func SplitRGB(color int) (r, g, b int) {
+	return color >> 16 & 0xFF, color >> 8 & 0xFF, color & 0xFF
+}
+
+// HSL converts a hue in degrees and saturation and lightness in [0, 1]
+// into a packed color
+func HSL(h, s, l float64) int {
+	s, l = clampUnit(s), clampUnit(l)
+	c := (1 - math.Abs(2*l-1)) * s
+	return hueToRGB(h, c, l-c/2)
+}
+
+// HSV converts a hue in degrees and saturation and value in [0, 1]
+// into a packed color
+func HSV(h, s, v float64) int {
+	s, v = clampUnit(s), clampUnit(v)
+	c := v * s
+	return hueToRGB(h, c, v-c)
+}
+
+// Kelvin approximates the color of a black body at the given temperature,
+// clamped to 1000K-40000K
+func Kelvin(temperature float64) int {
+	t := math.Max(1000, math.Min(40000, temperature)) / 100
+
+	var r, g, b float64
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+	switch {
+	case t >= 66:
+		b = 255
+	case t <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+	return RGB(round(r), round(g), round(b))
+}
+
+// ToHSL converts a packed color into a hue in degrees and saturation and
+// lightness in [0, 1]
+func ToHSL(color int) (h, s, l float64) {
+	h, max, min := hueOf(color)
+	l = (max + min) / 2
+	if max != min {
+		s = (max - min) / (1 - math.Abs(2*l-1))
+	}
+	return h, s, l
+}
+
+// ToHSV converts a packed color into a hue in degrees and saturation and
+// value in [0, 1]
+func ToHSV(color int) (h, s, v float64) {
+	h, max, min := hueOf(color)
+	if max != 0 {
+		s = (max - min) / max
+	}
+	return h, s, max
+}
+
+// ColorHex formats a color as #RRGGBB
+func ColorHex(color int) string {
+	return fmt.Sprintf("#%06X", color&0xFFFFFF)
+}
+
+// ColorName returns the CSS name of a color, if it has one
+func ColorName(color int) (string, bool) {
+	name, ok := cssNamesByColor[color&0xFFFFFF]
+	return name, ok
+}
+
+// ColorString formats a color for display, using its CSS name if it has
+// one and #RRGGBB otherwise
+func ColorString(color int) string {
+	if name, ok := ColorName(color); ok {
+		return name
+	}
+	return ColorHex(color)
+}
+
+// cssNamesByColor maps colors back to CSS names, preferring the
+// alphabetically first name when several share a color
+var cssNamesByColor = func() map[int]string {
+	names := make([]string, 0, len(cssColors))
+	for name := range cssColors {
+		names = append(names, name)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	m := make(map[int]string, len(cssColors))
+	for _, name := range names {
+		m[cssColors[name]] = name
+	}
+	return m
+}()
+
+// ParseColor parses a color into the packed integer format. Supported forms:
+//   - #RGB, #RRGGBB and 0xRRGGBB
+//   - rgb(r, g, b) with components 0-255 or percentages
+//   - hsl(h, s%, l%) and hsv(h, s%, v%)
+//   - CSS color names, e.g. "rebeccapurple"
+//   - color temperatures, e.g. "2700K"
+//   - decimal packed integers, e.g. "16711680"
+func ParseColor(s string) (int, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	invalid := func(reason string) (int, error) {
+		return 0, fmt.Errorf("%w %q: %s", ErrInvalidColor, s, reason)
+	}
+
+	if str == "" {
+		return invalid("empty")
+	}
+	// Names are looked up first, since some end in "k" like temperatures
+	if v, ok := cssColors[strings.ReplaceAll(str, " ", "")]; ok {
+		return v, nil
+	}
+
+	switch {
+	case strings.HasPrefix(str, "#"), strings.HasPrefix(str, "0x"):
+		hex := strings.TrimPrefix(strings.TrimPrefix(str, "#"), "0x")
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return invalid("expected 3 or 6 hex digits")
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return invalid("bad hex digits")
+		}
+		return int(v), nil
+	case strings.HasSuffix(str, "k"):
+		k, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(str, "k")), 64)
+		if err != nil || k <= 0 {
+			return invalid("bad color temperature")
+		}
+		return Kelvin(k), nil
+	case strings.HasSuffix(str, ")"):
+		open := strings.Index(str, "(")
+		if open < 0 {
+			return invalid("missing (")
+		}
+		fn := strings.TrimSpace(str[:open])
+		args := strings.FieldsFunc(str[open+1:len(str)-1], func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(args) != 3 {
+			return invalid("expected 3 components")
+		}
+		switch fn {
+		case "rgb":
+			var c [3]int
+			for i, arg := range args {
+				v, err := parseComponent(arg, 255)
+				if err != nil {
+					return invalid(err.Error())
+				}
+				c[i] = round(v)
+			}
+			return RGB(c[0], c[1], c[2]), nil
+		case "hsl", "hsv":
+			h, err := strconv.ParseFloat(strings.TrimSuffix(args[0], "deg"), 64)
+			if err != nil {
+				return invalid("bad hue")
+			}
+			a, err := parseComponent(args[1], 100)
+			if err != nil {
+				return invalid(err.Error())
+			}
+			b, err := parseComponent(args[2], 100)
+			if err != nil {
+				return invalid(err.Error())
+			}
+			if fn == "hsl" {
+				return HSL(h, a/100, b/100), nil
+			}
+			return HSV(h, a/100, b/100), nil
+		default:
+			return invalid("unknown function " + fn)
+		}
+	}
+
+	if v, err := strconv.ParseUint(str, 10, 32); err == nil && v <= 0xFFFFFF {
+		return int(v), nil
+	}
+	return invalid("unknown color")
+}
+
+// ParseColors parses each string with ParseColor
+func ParseColors(colors ...string) ([]int, error) {
+	parsed := make([]int, len(colors))
+	for i, c := range colors {
+		v, err := ParseColor(c)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = v
+	}
+	return parsed, nil
+}
+
+// NewColorContainerFrom creates a ColorContainer from any mix of packed
+// integers and strings accepted by ParseColor
+func NewColorContainerFrom(colors ...interface{}) (*ColorContainer, error) {
+	c := NewColorContainer(make([]int, 0, len(colors)))
+	for _, color := range colors {
+		switch v := color.(type) {
+		case int:
+			c.AddColor(v)
+		case int32:
+			c.AddColor(int(v))
+		case int64:
+			c.AddColor(int(v))
+		case uint32:
+			c.AddColor(int(v))
+		case string:
+			parsed, err := ParseColor(v)
+			if err != nil {
+				return nil, err
+			}
+			c.AddColor(parsed)
+		default:
+			return nil, fmt.Errorf("%w: unsupported type %T", ErrInvalidColor, color)
+		}
+	}
+	return c, nil
+}
+
+// Strings formats each color in the container with ColorString
+func (c *ColorContainer) Strings() []string {
+	strs := make([]string, len(c.Colors))
+	for i, color := range c.Colors {
+		strs[i] = ColorString(color)
+	}
+	return strs
+}
+
+// parseComponent parses a plain number, or a percentage of max
+func parseComponent(s string, max float64) (float64, error) {
+	percent := strings.HasSuffix(s, "%")
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad component %q", s)
+	}
+	if percent {
+		v = v / 100 * max
+	}
+	if v < 0 || v > max {
+		return 0, fmt.Errorf("component %q out of range", s)
+	}
+	return v, nil
+}
+
+// hueToRGB builds a color from a hue, chroma and the amount added to each
+// component to match the lightness or value
+func hueToRGB(h, c, m float64) int {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return RGB(round((r+m)*255), round((g+m)*255), round((b+m)*255))
+}
+
+// hueOf returns the hue of a color in degrees along with its largest and
+// smallest components in [0, 1]
+func hueOf(color int) (h, max, min float64) {
+	ri, gi, bi := SplitRGB(color)
+	r, g, b := float64(ri)/255, float64(gi)/255, float64(bi)/255
+	max, min = math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+
+	d := max - min
+	switch {
+	case d == 0:
+		h = 0
+	case max == r:
+		h = 60 * math.Mod((g-b)/d, 6)
+	case max == g:
+		h = 60 * ((b-r)/d + 2)
+	default:
+		h = 60 * ((r-g)/d + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	return h, max, min
+}
+
+func clampByte(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
+
+func clampUnit(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func round(v float64) int {
+	return int(math.Round(v))
+}
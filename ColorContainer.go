@@ -22,30 +22,89 @@
 
 package animatedledstrip
 
-type colorContainer struct {
+type ColorContainer struct {
 	ContainerType string `json:"type"`
 	Colors        []int  `json:"colors"`
 }
 
-func ColorContainer(colors []int) *colorContainer {
-	return &colorContainer{ContainerType: "ColorContainer", Colors: colors}
+func NewColorContainer(colors []int) *ColorContainer {
+	return &ColorContainer{ContainerType: "ColorContainer", Colors: colors}
 }
 
-func (c *colorContainer) AddColor(color int) *colorContainer {
+func (c *ColorContainer) AddColor(color int) *ColorContainer {
 	c.Colors = append(c.Colors, color)
 	return c
 }
 
-type preparedColorContainer struct {
+type PreparedColorContainer struct {
 	ContainerType  string `json:"type"`
 	Colors         []int  `json:"colors"`
 	OriginalColors []int  `json:"originalColors"`
 }
 
-func PreparedColorContainer(colors []int, originalColors []int) *preparedColorContainer {
-	return &preparedColorContainer{
+func NewPreparedColorContainer(colors []int, originalColors []int) *PreparedColorContainer {
+	return &PreparedColorContainer{
 		ContainerType:  "PreparedColorContainer",
 		Colors:         colors,
 		OriginalColors: originalColors,
 	}
 }
+
+// Prepare expands the container into a gradient across numLEDs pixels, like
+// the server does before running an animation. The colors are spread evenly
+// across the pixels, each pixel between two of them is blended linearly, and
+// the last color blends back into the first. The result hasn't been checked
+// against a server yet, so it may differ from the server's in rounding.
+func (c *ColorContainer) Prepare(numLEDs int) *PreparedColorContainer {
+	original := append([]int(nil), c.Colors...)
+	if numLEDs <= 0 {
+		return NewPreparedColorContainer([]int{}, original)
+	}
+	prepared := make([]int, numLEDs)
+	if len(c.Colors) == 0 {
+		return NewPreparedColorContainer(prepared, original)
+	}
+
+	spacing := float64(numLEDs) / float64(len(c.Colors))
+	purePixels := make([]int, len(c.Colors))
+	for i := range c.Colors {
+		purePixels[i] = round(spacing * float64(i))
+	}
+
+	for i := 0; i < numLEDs; i++ {
+		// Find the last pure pixel at or before i
+		k := 0
+		for j := len(purePixels) - 1; j >= 0; j-- {
+			if purePixels[j] <= i {
+				k = j
+				break
+			}
+		}
+		offset := i - purePixels[k]
+		if offset == 0 {
+			prepared[i] = c.Colors[k]
+			continue
+		}
+		amount := int(float64(offset) / spacing * 255)
+		prepared[i] = Blend(c.Colors[k], c.Colors[(k+1)%len(c.Colors)], amount)
+	}
+
+	return NewPreparedColorContainer(prepared, original)
+}
+
+// Blend mixes other into color by amount, where 0 returns color and 255
+// returns other
+func Blend(color, other, amount int) int {
+	if amount <= 0 {
+		return color
+	}
+	if amount >= 255 {
+		return other
+	}
+	r1, g1, b1 := SplitRGB(color)
+	r2, g2, b2 := SplitRGB(other)
+	mix := func(a, b int) int {
+		return (a*(255-amount) + b*amount) / 255
+	}
+	return RGB(mix(r1, r2), mix(g1, g2), mix(b1, b2))
+}
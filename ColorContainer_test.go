@@ -23,10 +23,107 @@
 package animatedledstrip
 
 import (
-	//"github.com/stretchr/testify/assert"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
+var recordPrepared = flag.String("record-prepared", "",
+	"record PreparedColorContainer fixtures from the server at this URL; leaves prepare-test-* sections on it")
+
+const preparedFixtures = "testdata/PreparedColorContainers.json"
+
+// preparedFixture is a color container as prepared by a real server
+type preparedFixture struct {
+	OriginalColors []int `json:"originalColors"`
+	NumLEDs        int   `json:"numLEDs"`
+	Colors         []int `json:"colors"`
+}
+
+// recordPreparedFixtures runs a Color animation for each gradient on a
+// section of each length and saves the colors the server prepared
+func recordPreparedFixtures(t *testing.T, serverURL string) {
+	gradients := [][]int{
+		{0xFF0000},
+		{0xFF0000, 0x0000FF},
+		{0xFF0000, 0x00FF00, 0x0000FF},
+		{0xFF0000, 0xFFFF00, 0x00FF00, 0x00FFFF, 0x0000FF},
+	}
+	lengths := []int{1, 2, 5, 7, 10, 60}
+
+	c := ALSHttpClient("", WithBaseURL(serverURL))
+	sections, err := c.GetSectionsMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixtures []*preparedFixture
+	for _, n := range lengths {
+		name := fmt.Sprintf("prepare-test-%d", n)
+		if _, ok := sections[name]; !ok {
+			if _, err := c.CreateNewSection(NewSectionFromRange(name, 0, n-1, "")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i, gradient := range gradients {
+			running, err := c.StartAnimation(&AnimationToRunParams{
+				Animation: "Color",
+				Id:        fmt.Sprintf("%s-%d", name, i),
+				Section:   name,
+				RunCount:  -1,
+				Colors:    []*ColorContainer{NewColorContainer(gradient)},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.EndAnimation(running.Id); err != nil {
+				t.Fatal(err)
+			}
+			fixtures = append(fixtures, &preparedFixture{OriginalColors: gradient, NumLEDs: n, Colors: running.Colors[0].Colors})
+		}
+	}
+
+	data, err := json.MarshalIndent(fixtures, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(preparedFixtures), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(preparedFixtures, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestColorContainer_PrepareMatchesServer checks Prepare against colors
+// recorded from a real server with
+//
+//	go test -run TestColorContainer_PrepareMatchesServer -record-prepared http://host:8080
+func TestColorContainer_PrepareMatchesServer(t *testing.T) {
+	if *recordPrepared != "" {
+		recordPreparedFixtures(t, *recordPrepared)
+	}
+	data, err := ioutil.ReadFile(preparedFixtures)
+	if os.IsNotExist(err) {
+		t.Skip("no recorded server fixtures; record them with -record-prepared")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	var fixtures []*preparedFixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fixtures {
+		prepared := NewColorContainer(f.OriginalColors).Prepare(f.NumLEDs)
+		assert.Equal(t, f.Colors, prepared.Colors, "%d colors over %d LEDs", len(f.OriginalColors), f.NumLEDs)
+	}
+}
+
 func TestColorContainer_AddColor(t *testing.T) {
 	//c := ColorContainer{}
 	//c.AddColor(0xFF)
@@ -40,3 +137,39 @@ func TestColorContainer_AddColor(t *testing.T) {
 	//assert.Equal(t, c.Colors[0], 0xFFFFFF)
 	//assert.Equal(t, c.Colors[1], 0xFFFF)
 }
+
+// The expectations below are worked out from the interpolation itself, not
+// taken from a server; TestColorContainer_PrepareMatchesServer only runs
+// once fixtures have been recorded
+
+func TestColorContainer_Prepare(t *testing.T) {
+	c := NewColorContainer([]int{0xFF0000, 0x0000FF})
+	p := c.Prepare(10)
+
+	assert.Equal(t, "PreparedColorContainer", p.ContainerType)
+	assert.Equal(t, []int{0xFF0000, 0x0000FF}, p.OriginalColors)
+	assert.Equal(t, []int{
+		0xFF0000, 0xCC0033, 0x990066, 0x660099, 0x3300CC,
+		0x0000FF, 0x3300CC, 0x660099, 0x990066, 0xCC0033,
+	}, p.Colors)
+}
+
+func TestColorContainer_PrepareUneven(t *testing.T) {
+	c := NewColorContainer([]int{0xFF0000, 0x00FF00, 0x0000FF})
+
+	assert.Equal(t, []int{0xFF0000, 0x669900, 0x00FF00, 0x0000FF, 0x990066}, c.Prepare(5).Colors)
+}
+
+func TestColorContainer_PrepareEdgeCases(t *testing.T) {
+	assert.Equal(t, []int{0xFF, 0xFF, 0xFF}, NewColorContainer([]int{0xFF}).Prepare(3).Colors)
+	assert.Equal(t, []int{0, 0}, NewColorContainer(nil).Prepare(2).Colors)
+	assert.Equal(t, []int{}, NewColorContainer([]int{0xFF}).Prepare(0).Colors)
+	// With more colors than pixels, the last color landing on a pixel wins
+	assert.Equal(t, []int{1, 3}, NewColorContainer([]int{1, 2, 3, 4}).Prepare(2).Colors)
+}
+
+func TestBlend(t *testing.T) {
+	assert.Equal(t, 0xFF0000, Blend(0xFF0000, 0x0000FF, 0))
+	assert.Equal(t, 0x0000FF, Blend(0xFF0000, 0x0000FF, 255))
+	assert.Equal(t, 0x7F0080, Blend(0xFF0000, 0x0000FF, 128))
+}
@@ -0,0 +1,143 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := map[string]int{
+		"#FF8000":                0xFF8000,
+		"#f80":                   0xFF8800,
+		"0x00ff00":               0x00FF00,
+		"  #0000ff ":             0x0000FF,
+		"rgb(255, 128, 0)":       0xFF8000,
+		"rgb(255 128 0)":         0xFF8000,
+		"RGB(100%, 0%, 50%)":     0xFF0080,
+		"hsl(0, 100%, 50%)":      0xFF0000,
+		"hsl(120deg, 100%, 25%)": 0x008000,
+		"hsl(240, 100, 50)":      0x0000FF,
+		"hsv(60, 100%, 100%)":    0xFFFF00,
+		"hsv(0, 0%, 50%)":        0x808080,
+		"rebeccapurple":          0x663399,
+		"Dark Orange":            0xFF8C00,
+		"6600K":                  0xFFFFFF,
+		"1900k":                  0xFF8400,
+		"16711680":               0xFF0000,
+	}
+
+	for input, expected := range tests {
+		c, err := ParseColor(input)
+		if assert.Nil(t, err, input) {
+			assert.Equal(t, ColorHex(expected), ColorHex(c), input)
+		}
+	}
+}
+
+func TestParseColor_NamesEndingInK(t *testing.T) {
+	c, err := ParseColor("black")
+	assert.Nil(t, err)
+	assert.Equal(t, 0x000000, c)
+
+	names := 0
+	for name, expected := range cssColors {
+		if !strings.HasSuffix(name, "k") {
+			continue
+		}
+		names++
+		c, err := ParseColor(name)
+		if assert.Nil(t, err, name) {
+			assert.Equal(t, expected, c, name)
+		}
+		c, err = ParseColor(strings.ToUpper(name))
+		assert.Nil(t, err, name)
+		assert.Equal(t, expected, c, name)
+	}
+	assert.Equal(t, 7, names)
+}
+
+func TestParseColors_Black(t *testing.T) {
+	colors, err := ParseColors("black", "darkblue")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0x000000, 0x00008B}, colors)
+}
+
+func TestParseColor_Invalid(t *testing.T) {
+	for _, input := range []string{"", "#12345", "#GGGGGG", "rgb(1, 2)", "rgb(256, 0, 0)", "cmyk(0, 0, 0)", "hsl(x, 1%, 1%)", "-5K", "notacolor", "99999999"} {
+		_, err := ParseColor(input)
+		assert.True(t, errors.Is(err, ErrInvalidColor), input)
+	}
+}
+
+func TestColorConversions(t *testing.T) {
+	r, g, b := SplitRGB(0x123456)
+	assert.Equal(t, []int{0x12, 0x34, 0x56}, []int{r, g, b})
+	assert.Equal(t, 0xFF00FF, RGB(300, -1, 255))
+
+	h, s, l := ToHSL(0x008000)
+	assert.InDelta(t, 120, h, 0.01)
+	assert.InDelta(t, 1, s, 0.01)
+	assert.InDelta(t, 0.25, l, 0.01)
+	assert.Equal(t, 0x008000, HSL(h, s, l))
+
+	h, s, v := ToHSV(0x663399)
+	assert.Equal(t, 0x663399, HSV(h, s, v))
+
+	assert.Equal(t, "#0A0B0C", ColorHex(0x0A0B0C))
+	name, ok := ColorName(0x00FFFF)
+	assert.True(t, ok)
+	assert.Equal(t, "aqua", name)
+	assert.Equal(t, "red", ColorString(0xFF0000))
+	assert.Equal(t, "#FF0001", ColorString(0xFF0001))
+}
+
+func TestKelvin(t *testing.T) {
+	assert.Equal(t, 0xFFFFFF, Kelvin(6600))
+	warm := Kelvin(2700)
+	r, g, b := SplitRGB(warm)
+	assert.Equal(t, 255, r)
+	assert.True(t, g > b)
+	assert.Equal(t, Kelvin(1000), Kelvin(10))
+}
+
+func TestNewColorContainerFrom(t *testing.T) {
+	c, err := NewColorContainerFrom(0xFF0000, "#00FF00", "blue", int64(0x010203), "2700K")
+	assert.Nil(t, err)
+	assert.Equal(t, "ColorContainer", c.ContainerType)
+	assert.Equal(t, []int{0xFF0000, 0x00FF00, 0x0000FF, 0x010203, Kelvin(2700)}, c.Colors)
+	assert.Equal(t, []string{"red", "lime", "blue", "#010203"}, c.Strings()[:4])
+
+	_, err = NewColorContainerFrom("blurple")
+	assert.True(t, errors.Is(err, ErrInvalidColor))
+	_, err = NewColorContainerFrom(1.5)
+	assert.True(t, errors.Is(err, ErrInvalidColor))
+
+	colors, err := ParseColors("red", "#000")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0xFF0000, 0}, colors)
+}
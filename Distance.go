@@ -1,14 +1,14 @@
 package animatedledstrip
 
-type distance struct {
+type Distance struct {
 	DistanceType string  `json:"type"`
 	X            float64 `json:"x"`
 	Y            float64 `json:"y"`
 	Z            float64 `json:"z"`
 }
 
-func AbsoluteDistance(x float64, y float64, z float64) *distance {
-	return &distance{
+func AbsoluteDistance(x float64, y float64, z float64) *Distance {
+	return &Distance{
 		DistanceType: "AbsoluteDistance",
 		X:            x,
 		Y:            y,
@@ -16,8 +16,8 @@ func AbsoluteDistance(x float64, y float64, z float64) *distance {
 	}
 }
 
-func PercentDistance(x float64, y float64, z float64) *distance {
-	return &distance{
+func PercentDistance(x float64, y float64, z float64) *Distance {
+	return &Distance{
 		DistanceType: "PercentDistance",
 		X:            x,
 		Y:            y,
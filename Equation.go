@@ -1,9 +1,9 @@
 package animatedledstrip
 
-type equation struct {
+type Equation struct {
 	Coefficients []float64 `json:"coefficients"`
 }
 
-func Equation(coefficients []float64) *equation {
-	return &equation{Coefficients: coefficients}
+func NewEquation(coefficients []float64) *Equation {
+	return &Equation{Coefficients: coefficients}
 }
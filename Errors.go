@@ -0,0 +1,94 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrBadRequest is matched by an *APIError for a 400 response
+	ErrBadRequest = errors.New("bad request")
+	// ErrNotFound is matched by an *APIError for a 404 response,
+	// such as when an animation, section or running animation does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrServer is matched by an *APIError for any 5xx response
+	ErrServer = errors.New("server error")
+	// ErrDecode is matched by a *DecodeError when a response body could not be decoded
+	ErrDecode = errors.New("could not decode response")
+)
+
+// APIError is returned when the server responds with a non-200 status code
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s to %s failed with %d", e.Method, e.URL, e.StatusCode)
+	}
+	return fmt.Sprintf("%s to %s failed with %d: %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// Is allows an *APIError to be matched against ErrBadRequest, ErrNotFound
+// and ErrServer with errors.Is
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrServer:
+		return e.StatusCode >= 500 && e.StatusCode < 600
+	default:
+		return false
+	}
+}
+
+// DecodeError is returned when the server responds successfully
+// but the response body could not be decoded
+type DecodeError struct {
+	Method string
+	URL    string
+	Body   []byte
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decoding response to %s to %s failed: %s", e.Method, e.URL, e.Err.Error())
+}
+
+// Unwrap returns the underlying error from encoding/json
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Is allows a *DecodeError to be matched against ErrDecode with errors.Is
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
@@ -0,0 +1,92 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIError_Is(t *testing.T) {
+	assert.True(t, errors.Is(&APIError{StatusCode: 400}, ErrBadRequest))
+	assert.True(t, errors.Is(&APIError{StatusCode: 404}, ErrNotFound))
+	assert.True(t, errors.Is(&APIError{StatusCode: 500}, ErrServer))
+	assert.True(t, errors.Is(&APIError{StatusCode: 503}, ErrServer))
+	assert.False(t, errors.Is(&APIError{StatusCode: 404}, ErrServer))
+	assert.False(t, errors.Is(&APIError{StatusCode: 401}, ErrBadRequest))
+	assert.False(t, errors.Is(&APIError{StatusCode: 500}, ErrDecode))
+}
+
+func TestAPIError_Error(t *testing.T) {
+	err := &APIError{Method: "GET", URL: "http://10.0.0.254:8080/running/1", StatusCode: 404}
+	assert.Equal(t, "GET to http://10.0.0.254:8080/running/1 failed with 404", err.Error())
+
+	err.Body = "Animation 1 not found"
+	assert.Equal(t, "GET to http://10.0.0.254:8080/running/1 failed with 404: Animation 1 not found", err.Error())
+}
+
+func TestALSHttpClient_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/running/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("Animation missing not found\n"))
+		case "/start":
+			w.WriteHeader(http.StatusBadRequest)
+		case "/strip/clear":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte("not json"))
+		}
+	}))
+	defer server.Close()
+
+	c := ALSHttpClient("", WithBaseURL(server.URL))
+
+	_, err := c.EndAnimation("missing")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.MethodDelete, apiErr.Method)
+	assert.Equal(t, server.URL+"/running/missing", apiErr.URL)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "Animation missing not found", apiErr.Body)
+
+	_, err = c.StartAnimation(&AnimationToRunParams{Animation: "Color"})
+	assert.True(t, errors.Is(err, ErrBadRequest))
+
+	err = c.ClearStrip()
+	assert.True(t, errors.Is(err, ErrServer))
+
+	_, err = c.GetStripInfo()
+	assert.True(t, errors.Is(err, ErrDecode))
+	var decodeErr *DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, http.MethodGet, decodeErr.Method)
+	assert.Equal(t, "not json", string(decodeErr.Body))
+	assert.NotNil(t, errors.Unwrap(decodeErr))
+}
@@ -0,0 +1,291 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventType identifies the kind of change an Event describes
+type EventType string
+
+const (
+	AnimationStarted EventType = "AnimationStarted"
+	AnimationEnded   EventType = "AnimationEnded"
+	SectionCreated   EventType = "SectionCreated"
+	StripCleared     EventType = "StripCleared"
+)
+
+// Event is a change in the state of the server.
+// Animation is set for AnimationStarted and AnimationEnded events,
+// Section is set for SectionCreated events.
+type Event struct {
+	Type      EventType               `json:"type"`
+	Animation *RunningAnimationParams `json:"animation,omitempty"`
+	Section   *Section                `json:"section,omitempty"`
+}
+
+// SubscribeOptions configures a subscription; a nil *SubscribeOptions uses the defaults
+type SubscribeOptions struct {
+	// StreamPath is the server-sent events endpoint (default "/events")
+	StreamPath string
+	// PollInterval is how often the server is polled when streaming is not available (default 1s)
+	PollInterval time.Duration
+	// DisableStream always uses polling
+	DisableStream bool
+}
+
+// Subscriber is implemented by clients that can deliver Events
+type Subscriber interface {
+	Subscribe(ctx context.Context, opts *SubscribeOptions) (<-chan *Event, error)
+}
+
+var _ Subscriber = (*aLSHttpClient)(nil)
+
+const eventBufferSize = 16
+
+func (o *SubscribeOptions) withDefaults() SubscribeOptions {
+	var opts SubscribeOptions
+	if o != nil {
+		opts = *o
+	}
+	if opts.StreamPath == "" {
+		opts.StreamPath = "/events"
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	return opts
+}
+
+// Subscribe delivers Events on the returned channel until ctx is done, when the channel is closed.
+// Events are read from the server's event stream if it has one.
+// If the stream can't be opened or is disconnected, the server is polled
+// instead and changes are detected by comparing running animations,
+// sections and the strip color.
+// A polling subscription only sends events for changes after it starts.
+// The stream request is sent with the client's *http.Client, so its Timeout should be 0.
+func (c *aLSHttpClient) Subscribe(ctx context.Context, opts *SubscribeOptions) (<-chan *Event, error) {
+	o := opts.withDefaults()
+	events := make(chan *Event, eventBufferSize)
+
+	if o.DisableStream {
+		go func() {
+			defer close(events)
+			pollEvents(ctx, c, o.PollInterval, events, c.logger)
+		}()
+		return events, nil
+	}
+
+	resp, err := c.openStream(ctx, o.StreamPath)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		c.logger.Debug("event stream not available, polling", "path", o.StreamPath, "error", err)
+		go func() {
+			defer close(events)
+			pollEvents(ctx, c, o.PollInterval, events, c.logger)
+		}()
+		return events, nil
+	}
+
+	go func() {
+		defer close(events)
+		err := c.readStream(ctx, resp, events)
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("event stream disconnected, polling", "path", o.StreamPath, "error", err)
+		pollEvents(ctx, c, o.PollInterval, events, c.logger)
+	}()
+	return events, nil
+}
+
+// sleepContext waits for d, returning false if ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (c *aLSHttpClient) openStream(ctx context.Context, path string) (*http.Response, error) {
+	url := c.resolvePath(path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		return nil, &APIError{Method: http.MethodGet, URL: url, StatusCode: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+// readStream reads server-sent events until the stream ends
+func (c *aLSHttpClient) readStream(ctx context.Context, resp *http.Response, events chan<- *Event) error {
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		} else if line != "" || len(data) == 0 {
+			continue
+		}
+		var event Event
+		err := json.Unmarshal([]byte(strings.Join(data, "\n")), &event)
+		data = nil
+		if err != nil {
+			c.logger.Error("decoding event failed", "error", err)
+			continue
+		}
+		select {
+		case events <- &event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return scanner.Err()
+}
+
+type pollState struct {
+	running  map[string]*RunningAnimationParams
+	sections map[string]*Section
+	cleared  bool
+}
+
+func takePollState(ctx context.Context, c Client) (*pollState, error) {
+	running, err := c.GetRunningAnimationsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := c.GetSectionsMapContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	color, err := c.GetCurrentStripColorContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cleared := true
+	for _, pixel := range color {
+		if pixel != 0 {
+			cleared = false
+			break
+		}
+	}
+	return &pollState{running: running, sections: sections, cleared: cleared}, nil
+}
+
+// diffPollState returns the events that turn prev into next, in a stable order
+func diffPollState(prev *pollState, next *pollState) []*Event {
+	var events []*Event
+	for _, id := range sortedKeys(prev.running) {
+		if _, ok := next.running[id]; !ok {
+			events = append(events, &Event{Type: AnimationEnded, Animation: prev.running[id]})
+		}
+	}
+	for _, id := range sortedKeys(next.running) {
+		if _, ok := prev.running[id]; !ok {
+			events = append(events, &Event{Type: AnimationStarted, Animation: next.running[id]})
+		}
+	}
+	names := make([]string, 0, len(next.sections))
+	for name := range next.sections {
+		if _, ok := prev.sections[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		events = append(events, &Event{Type: SectionCreated, Section: next.sections[name]})
+	}
+	if next.cleared && !prev.cleared {
+		events = append(events, &Event{Type: StripCleared})
+	}
+	return events
+}
+
+func sortedKeys(m map[string]*RunningAnimationParams) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pollEvents polls c every interval and sends the differences as events until ctx is done
+func pollEvents(ctx context.Context, c Client, interval time.Duration, events chan<- *Event, logger Logger) {
+	var prev *pollState
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		next, err := takePollState(ctx, c)
+		if ctx.Err() != nil {
+			return
+		} else if err != nil {
+			logger.Warn("polling for events failed", "error", err)
+		} else {
+			if prev != nil {
+				for _, event := range diffPollState(prev, next) {
+					select {
+					case events <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			prev = next
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/alstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func nextEvent(t *testing.T, events <-chan *als.Event) *als.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return nil
+	}
+}
+
+func exerciseEvents(t *testing.T, server *alstest.Server, events <-chan *als.Event) {
+	c := server.Client()
+
+	_, err := c.StartAnimation(&als.AnimationToRunParams{Animation: "Color", Id: "a"})
+	assert.Nil(t, err)
+	event := nextEvent(t, events)
+	assert.Equal(t, als.AnimationStarted, event.Type)
+	assert.Equal(t, "a", event.Animation.Id)
+
+	_, err = c.EndAnimation("a")
+	assert.Nil(t, err)
+	event = nextEvent(t, events)
+	assert.Equal(t, als.AnimationEnded, event.Type)
+	assert.Equal(t, "a", event.Animation.Id)
+
+	_, err = c.CreateNewSection(als.NewSection("right", []int{5, 6}, "fullStrip"))
+	assert.Nil(t, err)
+	event = nextEvent(t, events)
+	assert.Equal(t, als.SectionCreated, event.Type)
+	assert.Equal(t, "right", event.Section.Name)
+}
+
+func TestALSHttpClient_SubscribeStream(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := server.Client().(als.Subscriber).Subscribe(ctx, nil)
+	assert.Nil(t, err)
+
+	exerciseEvents(t, server, events)
+
+	assert.Nil(t, server.Client().ClearStrip())
+	assert.Equal(t, als.StripCleared, nextEvent(t, events).Type)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestALSHttpClient_SubscribePollingFallback(t *testing.T) {
+	server := alstest.NewServer(
+		alstest.WithNumLEDs(10),
+		alstest.WithAnimations(&als.AnimationInfo{Name: "Color"}),
+		alstest.WithoutEventStream(),
+	)
+	defer server.Close()
+	server.State.SetStripColor([]int{1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := server.Client().(als.Subscriber).Subscribe(ctx, &als.SubscribeOptions{PollInterval: 5 * time.Millisecond})
+	assert.Nil(t, err)
+
+	// Wait for the poller to take its first snapshot
+	assert.True(t, server.WaitForRequest("GET /strip/color", 2*time.Second))
+	exerciseEvents(t, server, events)
+
+	assert.Nil(t, server.Client().ClearStrip())
+	assert.Equal(t, als.StripCleared, nextEvent(t, events).Type)
+
+	cancel()
+	for range events {
+	}
+	assert.Contains(t, server.Requests(), "GET /events")
+}
+
+func TestALSHttpClient_SubscribeDisableStream(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := server.Client().(als.Subscriber).Subscribe(ctx,
+		&als.SubscribeOptions{PollInterval: 5 * time.Millisecond, DisableStream: true})
+	assert.Nil(t, err)
+	assert.True(t, server.WaitForRequest("GET /running", 2*time.Second))
+	cancel()
+	for range events {
+	}
+
+	assert.NotContains(t, server.Requests(), "GET /events")
+	assert.Contains(t, server.Requests(), "GET /running")
+}
+
+func TestALSHttpClient_SubscribeFallbackOnStreamError(t *testing.T) {
+	for name, fault := range map[string]alstest.Fault{
+		"server error": {Path: "/events", StatusCode: http.StatusInternalServerError},
+		"dropped":      {Path: "/events", Malformed: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := newTestServer()
+			defer server.Close()
+			server.AddFault(fault)
+			server.State.SetStripColor([]int{1})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			events, err := server.Client().(als.Subscriber).Subscribe(ctx, &als.SubscribeOptions{PollInterval: 5 * time.Millisecond})
+			assert.Nil(t, err)
+
+			assert.True(t, server.WaitForRequest("GET /strip/color", 2*time.Second))
+			exerciseEvents(t, server, events)
+
+			cancel()
+			for range events {
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FleetError collects the errors from a fleet operation by server name
+type FleetError struct {
+	Errors map[string]error
+}
+
+func (e *FleetError) Error() string {
+	names := e.names()
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", name, e.Errors[name].Error()))
+	}
+	return fmt.Sprintf("%d of the fleet failed: %s", len(names), strings.Join(msgs, "; "))
+}
+
+// Is reports whether any server's error matches target, so errors.Is can
+// look inside a FleetError
+func (e *FleetError) Is(target error) bool {
+	for _, name := range e.names() {
+		if errors.Is(e.Errors[name], target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first server's error, in name order, that matches target,
+// so errors.As can look inside a FleetError
+func (e *FleetError) As(target interface{}) bool {
+	for _, name := range e.names() {
+		if errors.As(e.Errors[name], target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *FleetError) names() []string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type fleetMember struct {
+	client Client
+	tags   map[string]bool
+}
+
+// Fleet holds many clients by name and runs operations on all of them concurrently
+type Fleet struct {
+	concurrency int
+
+	mu      sync.RWMutex
+	members map[string]*fleetMember
+}
+
+// NewFleet creates an empty fleet that runs at most concurrency
+// operations at once; 0 means no limit
+func NewFleet(concurrency int) *Fleet {
+	return &Fleet{concurrency: concurrency, members: map[string]*fleetMember{}}
+}
+
+// Add adds a client to the fleet, replacing any client with the same name
+func (f *Fleet) Add(name string, client Client, tags ...string) {
+	member := &fleetMember{client: client, tags: map[string]bool{}}
+	for _, tag := range tags {
+		member.tags[tag] = true
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members[name] = member
+}
+
+// Remove removes a client from the fleet
+func (f *Fleet) Remove(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.members, name)
+}
+
+// Client returns the client with the given name
+func (f *Fleet) Client(name string) (Client, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	member, ok := f.members[name]
+	if !ok {
+		return nil, false
+	}
+	return member.client, true
+}
+
+// Names returns the names of the clients in the fleet, sorted
+func (f *Fleet) Names() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	names := make([]string, 0, len(f.members))
+	for name := range f.members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Tagged returns a fleet of the clients that have every one of tags.
+// Clients added to or removed from f afterwards are not reflected in the returned fleet.
+func (f *Fleet) Tagged(tags ...string) *Fleet {
+	tagged := NewFleet(f.concurrency)
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+members:
+	for name, member := range f.members {
+		for _, tag := range tags {
+			if !member.tags[tag] {
+				continue members
+			}
+		}
+		tagged.members[name] = member
+	}
+	return tagged
+}
+
+// Do calls fn for every client in the fleet concurrently, limited by the fleet's concurrency.
+// It returns a *FleetError if any call failed.
+func (f *Fleet) Do(ctx context.Context, fn func(ctx context.Context, name string, client Client) error) error {
+	f.mu.RLock()
+	members := make(map[string]Client, len(f.members))
+	for name, member := range f.members {
+		members[name] = member.client
+	}
+	f.mu.RUnlock()
+
+	var sem chan struct{}
+	if f.concurrency > 0 {
+		sem = make(chan struct{}, f.concurrency)
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := map[string]error{}
+	for name, client := range members {
+		if sem != nil {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				mu.Lock()
+				errs[name] = ctx.Err()
+				mu.Unlock()
+				continue
+			}
+		}
+		wg.Add(1)
+		go func(name string, client Client) {
+			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			err := fn(ctx, name, client)
+			if err != nil {
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+			}
+		}(name, client)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return &FleetError{Errors: errs}
+	}
+	return nil
+}
+
+// StartAnimation starts newAnim on every client, returning the running animations by client name
+func (f *Fleet) StartAnimation(ctx context.Context, newAnim *AnimationToRunParams) (map[string]*RunningAnimationParams, error) {
+	var mu sync.Mutex
+	results := map[string]*RunningAnimationParams{}
+	err := f.Do(ctx, func(ctx context.Context, name string, client Client) error {
+		params, err := client.StartAnimationContext(ctx, newAnim)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		results[name] = params
+		return nil
+	})
+	return results, err
+}
+
+// ClearStrip clears the strip of every client
+func (f *Fleet) ClearStrip(ctx context.Context) error {
+	return f.Do(ctx, func(ctx context.Context, name string, client Client) error {
+		return client.ClearStripContext(ctx)
+	})
+}
+
+// EndAllAnimations ends every running animation on every client,
+// returning the ended animations by client name
+func (f *Fleet) EndAllAnimations(ctx context.Context) (map[string][]*RunningAnimationParams, error) {
+	var mu sync.Mutex
+	results := map[string][]*RunningAnimationParams{}
+	err := f.Do(ctx, func(ctx context.Context, name string, client Client) error {
+		ids, err := client.GetRunningAnimationsIdsContext(ctx)
+		if err != nil {
+			return err
+		}
+		ended := make([]*RunningAnimationParams, 0, len(ids))
+		var endErr error
+		for _, id := range ids {
+			params, err := client.EndAnimationContext(ctx, id)
+			if err != nil {
+				endErr = err
+				continue
+			}
+			ended = append(ended, params)
+		}
+		mu.Lock()
+		results[name] = ended
+		mu.Unlock()
+		return endErr
+	})
+	return results, err
+}
+
+// GetStripInfo fetches the strip info of every client by client name
+func (f *Fleet) GetStripInfo(ctx context.Context) (map[string]*StripInfo, error) {
+	var mu sync.Mutex
+	results := map[string]*StripInfo{}
+	err := f.Do(ctx, func(ctx context.Context, name string, client Client) error {
+		info, err := client.GetStripInfoContext(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		results[name] = info
+		return nil
+	})
+	return results, err
+}
@@ -0,0 +1,157 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/fake"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFleet() (*als.Fleet, map[string]*fake.Client) {
+	clients := map[string]*fake.Client{
+		"lobby-left":  fake.NewClient(10, &als.AnimationInfo{Name: "Color"}),
+		"lobby-right": fake.NewClient(20, &als.AnimationInfo{Name: "Color"}),
+		"office":      fake.NewClient(30),
+	}
+	fleet := als.NewFleet(2)
+	fleet.Add("lobby-left", clients["lobby-left"], "lobby", "left")
+	fleet.Add("lobby-right", clients["lobby-right"], "lobby")
+	fleet.Add("office", clients["office"])
+	return fleet, clients
+}
+
+func TestFleet_Membership(t *testing.T) {
+	fleet, clients := newTestFleet()
+
+	assert.Equal(t, []string{"lobby-left", "lobby-right", "office"}, fleet.Names())
+	assert.Equal(t, []string{"lobby-left", "lobby-right"}, fleet.Tagged("lobby").Names())
+	assert.Equal(t, []string{"lobby-left"}, fleet.Tagged("lobby", "left").Names())
+	assert.Len(t, fleet.Tagged("missing").Names(), 0)
+
+	client, ok := fleet.Client("office")
+	assert.True(t, ok)
+	assert.Equal(t, clients["office"], client)
+
+	fleet.Remove("office")
+	_, ok = fleet.Client("office")
+	assert.False(t, ok)
+}
+
+func TestFleet_GetStripInfo(t *testing.T) {
+	fleet, _ := newTestFleet()
+
+	infos, err := fleet.GetStripInfo(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, infos, 3)
+	assert.Equal(t, 20, infos["lobby-right"].NumLEDs)
+}
+
+func TestFleet_StartAndEndAnimations(t *testing.T) {
+	fleet, clients := newTestFleet()
+
+	started, err := fleet.StartAnimation(context.Background(), &als.AnimationToRunParams{Animation: "Color", Id: "all"})
+	var fleetErr *als.FleetError
+	assert.True(t, errors.As(err, &fleetErr))
+	assert.Len(t, fleetErr.Errors, 1)
+	assert.True(t, errors.Is(fleetErr.Errors["office"], als.ErrBadRequest))
+	assert.True(t, errors.Is(err, als.ErrBadRequest))
+	assert.False(t, errors.Is(err, als.ErrNotFound))
+	var apiErr *als.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 400, apiErr.StatusCode)
+	assert.Contains(t, err.Error(), "office: ")
+	assert.Len(t, started, 2)
+	assert.Equal(t, "all", started["lobby-left"].Id)
+
+	_, err = fleet.Tagged("lobby").StartAnimation(context.Background(), &als.AnimationToRunParams{Animation: "Color", Id: "lobby"})
+	assert.Nil(t, err)
+
+	ended, err := fleet.Tagged("lobby").EndAllAnimations(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, ended["lobby-left"], 2)
+	assert.Len(t, ended["lobby-right"], 2)
+
+	ids, _ := clients["lobby-left"].GetRunningAnimationsIds()
+	assert.Len(t, ids, 0)
+}
+
+func TestFleet_ClearStrip(t *testing.T) {
+	fleet, clients := newTestFleet()
+	clients["office"].SetStripColor([]int{1, 2, 3})
+
+	assert.Nil(t, fleet.ClearStrip(context.Background()))
+	color, _ := clients["office"].GetCurrentStripColor()
+	assert.Equal(t, make([]int, 30), color)
+}
+
+func TestFleet_ConcurrencyLimit(t *testing.T) {
+	fleet := als.NewFleet(2)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		fleet.Add(name, fake.NewClient(1))
+	}
+
+	var running, maxRunning int32
+	started := make(chan struct{}, 5)
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- fleet.Do(context.Background(), func(ctx context.Context, name string, client als.Client) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			started <- struct{}{}
+			<-release
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}()
+
+	// Hold the first two workers until both have started
+	<-started
+	<-started
+	assert.Equal(t, int32(2), atomic.LoadInt32(&running))
+	close(release)
+
+	assert.Nil(t, <-done)
+	assert.Len(t, started, 3)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+}
+
+func TestFleet_ContextCanceled(t *testing.T) {
+	fleet, _ := newTestFleet()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := fleet.ClearStrip(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,106 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type logEntry struct {
+	level string
+	msg   string
+	args  map[string]interface{}
+}
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordingLogger) record(level string, msg string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry := logEntry{level: level, msg: msg, args: map[string]interface{}{}}
+	for i := 0; i+1 < len(args); i += 2 {
+		entry.args[fmt.Sprint(args[i])] = args[i+1]
+	}
+	l.entries = append(l.entries, entry)
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.record("debug", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.record("warn", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.record("error", msg, args) }
+
+func TestALSHttpClient_Logging(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/strip/color":
+			_, _ = w.Write([]byte(`[1,2,3]`))
+		case "/strip/info":
+			_, _ = w.Write([]byte(`bad`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	c := ALSHttpClient("", WithBaseURL(server.URL), WithLogger(logger))
+
+	_, err := c.GetCurrentStripColor()
+	assert.Nil(t, err)
+	_, err = c.GetSection("missing")
+	assert.NotNil(t, err)
+	_, err = c.GetStripInfo()
+	assert.NotNil(t, err)
+
+	assert.Len(t, logger.entries, 4)
+
+	assert.Equal(t, "debug", logger.entries[0].level)
+	assert.Equal(t, "GET", logger.entries[0].args["method"])
+	assert.Equal(t, "/strip/color", logger.entries[0].args["path"])
+	assert.Equal(t, 200, logger.entries[0].args["status"])
+	assert.Equal(t, 7, logger.entries[0].args["size"])
+	assert.Contains(t, logger.entries[0].args, "latency")
+
+	assert.Equal(t, "warn", logger.entries[1].level)
+	assert.Equal(t, "/section/missing", logger.entries[1].args["path"])
+	assert.Equal(t, 404, logger.entries[1].args["status"])
+
+	assert.Equal(t, "debug", logger.entries[2].level)
+	assert.Equal(t, "error", logger.entries[3].level)
+	assert.Equal(t, "decoding response failed", logger.entries[3].msg)
+	assert.Equal(t, "/strip/info", logger.entries[3].args["path"])
+}
+
+func TestALSHttpClient_NilLogger(t *testing.T) {
+	c := ALSHttpClient("10.0.0.254", WithLogger(nil))
+
+	assert.Equal(t, noopLogger{}, c.logger)
+}
@@ -0,0 +1,325 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DesiredState describes the sections and animations a server should have.
+// Animations without an Id are given one derived from their params, so the
+// same spec always maps to the same running animation and editing an
+// animation's params replaces it. An animation with an explicit Id is
+// replaced too when its params differ from the running animation's
+// SourceParams.
+type DesiredState struct {
+	Sections   []*Section              `json:"sections"`
+	Animations []*AnimationToRunParams `json:"animations"`
+}
+
+// ReadDesiredState decodes a JSON DesiredState
+func ReadDesiredState(r io.Reader) (*DesiredState, error) {
+	var state DesiredState
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// LoadDesiredState reads a JSON DesiredState from a file
+func LoadDesiredState(path string) (*DesiredState, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadDesiredState(f)
+}
+
+// ActionType is the kind of change a PlanAction makes
+type ActionType string
+
+const (
+	CreateSectionAction  ActionType = "create-section"
+	EndAnimationAction   ActionType = "end-animation"
+	StartAnimationAction ActionType = "start-animation"
+)
+
+// PlanAction is a single change needed to reach the desired state
+type PlanAction struct {
+	Type ActionType
+	// Section is set for CreateSectionAction
+	Section *Section
+	// Animation is set for StartAnimationAction, with its Id filled in
+	Animation *AnimationToRunParams
+	// Running is set for EndAnimationAction
+	Running *RunningAnimationParams
+}
+
+func (a *PlanAction) String() string {
+	switch a.Type {
+	case CreateSectionAction:
+		return fmt.Sprintf("+ section %s (%d pixels, parent %s)", a.Section.Name, len(a.Section.Pixels), sectionOrFullStrip(a.Section.ParentSectionName))
+	case StartAnimationAction:
+		return fmt.Sprintf("+ animation %s on %s (id %s)", a.Animation.Animation, sectionOrFullStrip(a.Animation.Section), a.Animation.Id)
+	case EndAnimationAction:
+		return fmt.Sprintf("- animation %s on %s (id %s)", a.Running.AnimationName, sectionOrFullStrip(a.Running.Section), a.Running.Id)
+	}
+	return string(a.Type)
+}
+
+// Plan is the ordered list of changes that converge a server to a DesiredState.
+// Sections are created first, then extra and changed animations are ended,
+// then missing and changed animations are started.
+type Plan struct {
+	Actions []*PlanAction
+	// Conflicts lists desired sections that exist on the server with
+	// different pixels or parents. Sections cannot be modified, so a plan
+	// with conflicts cannot be applied.
+	Conflicts []string
+}
+
+// Empty reports whether the server is already in the desired state
+func (p *Plan) Empty() bool {
+	return len(p.Actions) == 0 && len(p.Conflicts) == 0
+}
+
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "No changes"
+	}
+	lines := make([]string, 0, len(p.Actions)+len(p.Conflicts))
+	for _, c := range p.Conflicts {
+		lines = append(lines, "! "+c)
+	}
+	for _, a := range p.Actions {
+		lines = append(lines, a.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Reconciler converges a server to a DesiredState
+type Reconciler struct {
+	client Client
+}
+
+func NewReconciler(client Client) *Reconciler {
+	return &Reconciler{client: client}
+}
+
+// Plan diffs desired against the server's sections and running animations
+func (r *Reconciler) Plan(ctx context.Context, desired *DesiredState) (*Plan, error) {
+	sections, err := r.client.GetSectionsMapContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	running, err := r.client.GetRunningAnimationsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	var toCreate []*Section
+	for _, sect := range desired.Sections {
+		existing, ok := sections[sect.Name]
+		if !ok {
+			toCreate = append(toCreate, sect)
+			continue
+		}
+		if !reflect.DeepEqual(existing.Pixels, sect.Pixels) {
+			plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("section %s exists with different pixels", sect.Name))
+		} else if sect.ParentSectionName != "" && existing.ParentSectionName != sect.ParentSectionName {
+			plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("section %s exists with parent %s, not %s",
+				sect.Name, existing.ParentSectionName, sect.ParentSectionName))
+		}
+	}
+	for _, sect := range orderByParent(toCreate) {
+		plan.Actions = append(plan.Actions, &PlanAction{Type: CreateSectionAction, Section: sect})
+	}
+
+	wanted := make(map[string]*AnimationToRunParams, len(desired.Animations))
+	changed := make(map[string]bool)
+	var ids []string
+	for _, anim := range desired.Animations {
+		withId := *anim
+		if withId.Id == "" {
+			withId.Id, err = StableAnimationId(anim)
+			if err != nil {
+				return nil, err
+			}
+		} else if existing, ok := running[withId.Id]; ok && existing.SourceParams != nil &&
+			!sameAnimation(&withId, existing.SourceParams) {
+			// Stable ids change with the params, but explicit ones don't
+			changed[withId.Id] = true
+		}
+		if _, ok := wanted[withId.Id]; ok {
+			return nil, fmt.Errorf("animation id %s is used more than once", withId.Id)
+		}
+		wanted[withId.Id] = &withId
+		ids = append(ids, withId.Id)
+	}
+
+	runningIds := make([]string, 0, len(running))
+	for id := range running {
+		runningIds = append(runningIds, id)
+	}
+	sort.Strings(runningIds)
+	for _, id := range runningIds {
+		if _, ok := wanted[id]; !ok || changed[id] {
+			plan.Actions = append(plan.Actions, &PlanAction{Type: EndAnimationAction, Running: running[id]})
+		}
+	}
+	for _, id := range ids {
+		if _, ok := running[id]; !ok || changed[id] {
+			plan.Actions = append(plan.Actions, &PlanAction{Type: StartAnimationAction, Animation: wanted[id]})
+		}
+	}
+
+	return plan, nil
+}
+
+// Apply carries out a plan, stopping at the first error
+func (r *Reconciler) Apply(ctx context.Context, plan *Plan) error {
+	if len(plan.Conflicts) > 0 {
+		return fmt.Errorf("plan has conflicts: %s", strings.Join(plan.Conflicts, "; "))
+	}
+	for _, action := range plan.Actions {
+		var err error
+		switch action.Type {
+		case CreateSectionAction:
+			_, err = r.client.CreateNewSectionContext(ctx, action.Section)
+		case EndAnimationAction:
+			_, err = r.client.EndAnimationContext(ctx, action.Running.Id)
+		case StartAnimationAction:
+			_, err = r.client.StartAnimationContext(ctx, action.Animation)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", action, err)
+		}
+	}
+	return nil
+}
+
+// Reconcile plans the changes needed to reach desired and, unless dryRun is
+// set, applies them. The plan is returned either way.
+func (r *Reconciler) Reconcile(ctx context.Context, desired *DesiredState, dryRun bool) (*Plan, error) {
+	plan, err := r.Plan(ctx, desired)
+	if err != nil || dryRun {
+		return plan, err
+	}
+	return plan, r.Apply(ctx, plan)
+}
+
+// StableAnimationId derives an Id from the animation's params, ignoring any
+// Id already set
+func StableAnimationId(anim *AnimationToRunParams) (string, error) {
+	withoutId := *anim
+	withoutId.Id = ""
+	data, err := json.Marshal(&withoutId)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "apply-" + hex.EncodeToString(sum[:6]), nil
+}
+
+// sameAnimation reports whether desired and source describe the same
+// animation, treating empty and missing fields alike
+func sameAnimation(desired *AnimationToRunParams, source *AnimationToRunParams) bool {
+	return reflect.DeepEqual(normalizeParams(desired), normalizeParams(source))
+}
+
+func normalizeParams(p *AnimationToRunParams) *AnimationToRunParams {
+	c := p.Copy()
+	c.Id = ""
+	c.Section = sectionOrFullStrip(c.Section)
+	if len(c.Colors) == 0 {
+		c.Colors = nil
+	}
+	if len(c.IntParams) == 0 {
+		c.IntParams = nil
+	}
+	if len(c.DoubleParams) == 0 {
+		c.DoubleParams = nil
+	}
+	if len(c.StringParams) == 0 {
+		c.StringParams = nil
+	}
+	if len(c.LocationParams) == 0 {
+		c.LocationParams = nil
+	}
+	if len(c.DistanceParams) == 0 {
+		c.DistanceParams = nil
+	}
+	if len(c.RotationParams) == 0 {
+		c.RotationParams = nil
+	}
+	if len(c.EquationParams) == 0 {
+		c.EquationParams = nil
+	}
+	return c
+}
+
+// orderByParent orders sections so each is created after any parent that is
+// also being created
+func orderByParent(sections []*Section) []*Section {
+	byName := make(map[string]*Section, len(sections))
+	for _, sect := range sections {
+		byName[sect.Name] = sect
+	}
+	ordered := make([]*Section, 0, len(sections))
+	added := make(map[string]bool, len(sections))
+	var add func(sect *Section, depth int)
+	add = func(sect *Section, depth int) {
+		if added[sect.Name] || depth > len(sections) {
+			return
+		}
+		if parent, ok := byName[sect.ParentSectionName]; ok {
+			add(parent, depth+1)
+		}
+		added[sect.Name] = true
+		ordered = append(ordered, sect)
+	}
+	for _, sect := range sections {
+		add(sect, 0)
+	}
+	return ordered
+}
+
+func sectionOrFullStrip(name string) string {
+	if name == "" {
+		return "fullStrip"
+	}
+	return name
+}
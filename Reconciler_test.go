@@ -0,0 +1,149 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/fake"
+	"github.com/stretchr/testify/assert"
+)
+
+const desiredStateJson = `{
+  "sections": [
+    {"name": "leftEnd", "pixels": [0, 1, 2], "parentSectionName": "left"},
+    {"name": "left", "pixels": [0, 1, 2, 3, 4], "parentSectionName": "fullStrip"}
+  ],
+  "animations": [
+    {"animation": "Color", "section": "left", "colors": [{"type": "ColorContainer", "colors": [255]}]},
+    {"animation": "Meteor", "id": "meteor", "section": "leftEnd"}
+  ]
+}`
+
+func TestReconciler(t *testing.T) {
+	client := fake.NewClient(10, &als.AnimationInfo{Name: "Color"}, &als.AnimationInfo{Name: "Meteor"})
+	_, err := client.StartAnimation(&als.AnimationToRunParams{Animation: "Color", Id: "old"})
+	assert.Nil(t, err)
+
+	desired, err := als.ReadDesiredState(strings.NewReader(desiredStateJson))
+	assert.Nil(t, err)
+	r := als.NewReconciler(client)
+
+	plan, err := r.Reconcile(context.Background(), desired, true)
+	assert.Nil(t, err)
+	colorId, _ := als.StableAnimationId(desired.Animations[0])
+	assert.Equal(t, strings.Join([]string{
+		"+ section left (5 pixels, parent fullStrip)",
+		"+ section leftEnd (3 pixels, parent left)",
+		"- animation Color on fullStrip (id old)",
+		"+ animation Color on left (id " + colorId + ")",
+		"+ animation Meteor on leftEnd (id meteor)",
+	}, "\n"), plan.String())
+
+	// Dry run makes no changes
+	ids, _ := client.GetRunningAnimationsIds()
+	assert.Equal(t, []string{"old"}, ids)
+
+	plan, err = r.Reconcile(context.Background(), desired, false)
+	assert.Nil(t, err)
+	assert.Len(t, plan.Actions, 5)
+
+	ids, _ = client.GetRunningAnimationsIds()
+	assert.ElementsMatch(t, []string{colorId, "meteor"}, ids)
+	sections, _ := client.GetSectionsMap()
+	assert.Equal(t, "left", sections["leftEnd"].ParentSectionName)
+
+	// Applying again is a no-op
+	plan, err = r.Plan(context.Background(), desired)
+	assert.Nil(t, err)
+	assert.True(t, plan.Empty())
+	assert.Equal(t, "No changes", plan.String())
+}
+
+func TestReconciler_ChangedParamsReplaceAnimation(t *testing.T) {
+	client := fake.NewClient(10, &als.AnimationInfo{Name: "Color"})
+	r := als.NewReconciler(client)
+	desired := &als.DesiredState{Animations: []*als.AnimationToRunParams{als.NewAnimation("Color").Color(0xFF).Build()}}
+
+	_, err := r.Reconcile(context.Background(), desired, false)
+	assert.Nil(t, err)
+
+	desired.Animations[0] = als.NewAnimation("Color").Color(0xFF00).Build()
+	plan, err := r.Plan(context.Background(), desired)
+	assert.Nil(t, err)
+	assert.Len(t, plan.Actions, 2)
+	assert.Equal(t, als.EndAnimationAction, plan.Actions[0].Type)
+	assert.Equal(t, als.StartAnimationAction, plan.Actions[1].Type)
+}
+
+func TestReconciler_ChangedParamsReplaceExplicitId(t *testing.T) {
+	client := fake.NewClient(10, &als.AnimationInfo{Name: "Color"})
+	r := als.NewReconciler(client)
+	desired := &als.DesiredState{Animations: []*als.AnimationToRunParams{
+		als.NewAnimation("Color").Id("lobby").Color(0xFF).Build(),
+	}}
+
+	_, err := r.Reconcile(context.Background(), desired, false)
+	assert.Nil(t, err)
+	plan, err := r.Plan(context.Background(), desired)
+	assert.Nil(t, err)
+	assert.True(t, plan.Empty())
+
+	desired.Animations[0] = als.NewAnimation("Color").Id("lobby").Color(0xFF00).Build()
+	plan, err = r.Reconcile(context.Background(), desired, false)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"- animation Color on fullStrip (id lobby)",
+		"+ animation Color on fullStrip (id lobby)",
+	}, "\n"), plan.String())
+
+	running, _ := client.GetRunningAnimationParams("lobby")
+	assert.Equal(t, []int{0xFF00}, running.SourceParams.Colors[0].Colors)
+}
+
+func TestReconciler_Conflicts(t *testing.T) {
+	client := fake.NewClient(10)
+	_, err := client.CreateNewSection(als.NewSectionFromRange("left", 0, 4, ""))
+	assert.Nil(t, err)
+
+	r := als.NewReconciler(client)
+	desired := &als.DesiredState{Sections: []*als.Section{als.NewSectionFromRange("left", 0, 5, "")}}
+
+	plan, err := r.Reconcile(context.Background(), desired, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"section left exists with different pixels"}, plan.Conflicts)
+	assert.NotNil(t, r.Apply(context.Background(), plan))
+
+	_, err = r.Plan(context.Background(), &als.DesiredState{Animations: []*als.AnimationToRunParams{
+		{Animation: "Color", Id: "same"}, {Animation: "Color", Id: "same"},
+	}})
+	assert.NotNil(t, err)
+}
+
+func TestReadDesiredState_UnknownField(t *testing.T) {
+	_, err := als.ReadDesiredState(strings.NewReader(`{"section": []}`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,172 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.Jitter = 0
+	return policy
+}
+
+func flakyServer(failures int32, status int) (*httptest.Server, *int32) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"1","animationName":"Color"}`))
+	}))
+	return server, &count
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(0))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 800*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, time.Second, policy.backoff(4))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		delay := policy.backoff(1)
+		assert.True(t, delay > 100*time.Millisecond && delay <= 200*time.Millisecond)
+	}
+}
+
+func TestRetryPolicy_Retryable(t *testing.T) {
+	policy := DefaultRetryPolicy()
+
+	assert.True(t, policy.retryable(&APIError{StatusCode: 503}))
+	assert.False(t, policy.retryable(&APIError{StatusCode: 404}))
+	assert.True(t, policy.retryable(errors.New("connection reset")))
+	assert.False(t, policy.retryable(context.Canceled))
+	assert.False(t, policy.retryable(context.DeadlineExceeded))
+}
+
+func TestALSHttpClient_RetriesIdempotent(t *testing.T) {
+	server, count := flakyServer(2, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	c := ALSHttpClient("", WithBaseURL(server.URL), WithRetryPolicy(testRetryPolicy()), WithLogger(logger))
+	params, err := c.GetRunningAnimationParams("1")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", params.Id)
+	assert.Equal(t, int32(3), atomic.LoadInt32(count))
+	retries := 0
+	for _, entry := range logger.entries {
+		if entry.msg == "retrying request" {
+			assert.Equal(t, "/running/1", entry.args["path"])
+			retries++
+		}
+	}
+	assert.Equal(t, 2, retries)
+
+	atomic.StoreInt32(count, 0)
+	_, err = c.EndAnimation("1")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(count))
+}
+
+func TestALSHttpClient_RetryGivesUp(t *testing.T) {
+	server, count := flakyServer(5, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	c := ALSHttpClient("", WithBaseURL(server.URL), WithRetryPolicy(testRetryPolicy()))
+	_, err := c.GetRunningAnimationParams("1")
+	assert.True(t, errors.Is(err, ErrServer))
+	assert.Equal(t, int32(3), atomic.LoadInt32(count))
+}
+
+func TestALSHttpClient_NoRetryForNonRetryableStatus(t *testing.T) {
+	server, count := flakyServer(5, http.StatusNotFound)
+	defer server.Close()
+
+	c := ALSHttpClient("", WithBaseURL(server.URL), WithRetryPolicy(testRetryPolicy()))
+	_, err := c.GetRunningAnimationParams("1")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.Equal(t, int32(1), atomic.LoadInt32(count))
+}
+
+func TestALSHttpClient_NoRetryByDefault(t *testing.T) {
+	server, count := flakyServer(1, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	c := ALSHttpClient("", WithBaseURL(server.URL))
+	_, err := c.GetRunningAnimationParams("1")
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(count))
+}
+
+func TestALSHttpClient_RetryStartAnimation(t *testing.T) {
+	server, count := flakyServer(1, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	policy := testRetryPolicy()
+	c := ALSHttpClient("", WithBaseURL(server.URL), WithRetryPolicy(policy))
+
+	_, err := c.StartAnimation(&AnimationToRunParams{Animation: "Color", Id: "1"})
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(count))
+
+	policy.RetryStartAnimationWithId = true
+	atomic.StoreInt32(count, 0)
+	_, err = c.StartAnimation(&AnimationToRunParams{Animation: "Color"})
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(count))
+
+	atomic.StoreInt32(count, 0)
+	params, err := c.StartAnimation(&AnimationToRunParams{Animation: "Color", Id: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", params.Id)
+	assert.Equal(t, int32(2), atomic.LoadInt32(count))
+}
+
+func TestALSHttpClient_RetryStopsOnContextDone(t *testing.T) {
+	server, _ := flakyServer(5, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	policy := testRetryPolicy()
+	policy.InitialBackoff = time.Hour
+	c := ALSHttpClient("", WithBaseURL(server.URL), WithRetryPolicy(policy))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := c.GetStripInfoContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
@@ -1,6 +1,6 @@
 package animatedledstrip
 
-type rotation struct {
+type Rotation struct {
 	RotationType  string   `json:"type"`
 	XRotation     float64  `json:"xRotation"`
 	YRotation     float64  `json:"yRotation"`
@@ -8,8 +8,8 @@ type rotation struct {
 	RotationOrder []string `json:"rotationOrder"`
 }
 
-func DegreesRotation(xRotation float64, yRotation float64, zRotation float64, rotationOrder []string) *rotation {
-	return &rotation{
+func DegreesRotation(xRotation float64, yRotation float64, zRotation float64, rotationOrder []string) *Rotation {
+	return &Rotation{
 		RotationType:  "DegreesRotation",
 		XRotation:     xRotation,
 		YRotation:     yRotation,
@@ -18,8 +18,8 @@ func DegreesRotation(xRotation float64, yRotation float64, zRotation float64, ro
 	}
 }
 
-func RadiansRotation(xRotation float64, yRotation float64, zRotation float64, rotationOrder []string) *rotation {
-	return &rotation{
+func RadiansRotation(xRotation float64, yRotation float64, zRotation float64, rotationOrder []string) *Rotation {
+	return &Rotation{
 		RotationType:  "RadiansRotation",
 		XRotation:     xRotation,
 		YRotation:     yRotation,
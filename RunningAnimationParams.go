@@ -1,26 +1,26 @@
 package animatedledstrip
 
-type runningAnimationParams struct {
+type RunningAnimationParams struct {
 	AnimationName  string                    `json:"animationName"`
-	Colors         []*preparedColorContainer `json:"colors"`
+	Colors         []*PreparedColorContainer `json:"colors"`
 	Id             string                    `json:"id"`
 	Section        string                    `json:"section"`
 	RunCount       int                       `json:"runCount"`
 	IntParams      map[string]int            `json:"intParams"`
 	DoubleParams   map[string]float64        `json:"doubleParams"`
 	StringParams   map[string]string         `json:"stringParams"`
-	LocationParams map[string]*location      `json:"locationParams"`
-	DistanceParams map[string]*distance      `json:"distanceParams"`
-	RotationParams map[string]*rotation      `json:"rotationParams"`
-	EquationParams map[string]*equation      `json:"equationParams"`
-	SourceParams   *animationToRunParams     `json:"sourceParams"`
+	LocationParams map[string]*Location      `json:"locationParams"`
+	DistanceParams map[string]*Distance      `json:"distanceParams"`
+	RotationParams map[string]*Rotation      `json:"rotationParams"`
+	EquationParams map[string]*Equation      `json:"equationParams"`
+	SourceParams   *AnimationToRunParams     `json:"sourceParams"`
 }
 
-func RunningAnimationParams(animationName string, colors []*preparedColorContainer, id string, section string,
+func NewRunningAnimationParams(animationName string, colors []*PreparedColorContainer, id string, section string,
 	runCount int, intParams map[string]int, doubleParams map[string]float64, stringParams map[string]string,
-	locationParams map[string]*location, distanceParams map[string]*distance, rotationParams map[string]*rotation,
-	equationParams map[string]*equation, sourceParams *animationToRunParams) *runningAnimationParams {
-	return &runningAnimationParams{
+	locationParams map[string]*Location, distanceParams map[string]*Distance, rotationParams map[string]*Rotation,
+	equationParams map[string]*Equation, sourceParams *AnimationToRunParams) *RunningAnimationParams {
+	return &RunningAnimationParams{
 		AnimationName:  animationName,
 		Colors:         colors,
 		Id:             id,
@@ -36,3 +36,33 @@ func RunningAnimationParams(animationName string, colors []*preparedColorContain
 		SourceParams:   sourceParams,
 	}
 }
+
+// Copy returns a deep copy of the params, including SourceParams, so they
+// can be changed without affecting the original
+func (p *RunningAnimationParams) Copy() *RunningAnimationParams {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	if p.Colors != nil {
+		c.Colors = make([]*PreparedColorContainer, len(p.Colors))
+		for i, cc := range p.Colors {
+			if cc == nil {
+				continue
+			}
+			cp := *cc
+			cp.Colors = copyInts(cc.Colors)
+			cp.OriginalColors = copyInts(cc.OriginalColors)
+			c.Colors[i] = &cp
+		}
+	}
+	c.IntParams = copyIntParams(p.IntParams)
+	c.DoubleParams = copyDoubleParams(p.DoubleParams)
+	c.StringParams = copyStringParams(p.StringParams)
+	c.LocationParams = copyLocationParams(p.LocationParams)
+	c.DistanceParams = copyDistanceParams(p.DistanceParams)
+	c.RotationParams = copyRotationParams(p.RotationParams)
+	c.EquationParams = copyEquationParams(p.EquationParams)
+	c.SourceParams = p.SourceParams.Copy()
+	return &c
+}
@@ -22,16 +22,229 @@
 
 package animatedledstrip
 
-type section struct {
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+type Section struct {
 	Name              string `json:"name"`
 	Pixels            []int  `json:"pixels"`
 	ParentSectionName string `json:"parentSectionName"`
 }
 
-func Section(name string, pixels []int, parentSectionName string) *section {
-	return &section{
+func NewSection(name string, pixels []int, parentSectionName string) *Section {
+	return &Section{
 		Name:              name,
 		Pixels:            pixels,
 		ParentSectionName: parentSectionName,
 	}
 }
+
+// PixelRange returns the pixels from start to end, inclusive. If end is
+// before start the pixels are in descending order.
+func PixelRange(start int, end int) []int {
+	step := 1
+	if end < start {
+		step = -1
+	}
+	pixels := make([]int, 0, (end-start)*step+1)
+	for p := start; p != end+step; p += step {
+		pixels = append(pixels, p)
+	}
+	return pixels
+}
+
+// NewSectionFromRange creates a section containing the physical pixels from
+// start to end, inclusive
+func NewSectionFromRange(name string, start int, end int, parentSectionName string) *Section {
+	return NewSection(name, PixelRange(start, end), parentSectionName)
+}
+
+// NumLEDs returns the number of pixels in the section
+func (s *Section) NumLEDs() int {
+	return len(s.Pixels)
+}
+
+// Contains reports whether the physical pixel is part of the section
+func (s *Section) Contains(pixel int) bool {
+	for _, p := range s.Pixels {
+		if p == pixel {
+			return true
+		}
+	}
+	return false
+}
+
+// PhysicalIndex maps an index within the section to the physical pixel
+func (s *Section) PhysicalIndex(local int) (int, error) {
+	if local < 0 || local >= len(s.Pixels) {
+		return 0, fmt.Errorf("index %d out of range for section %s with %d pixels", local, s.Name, len(s.Pixels))
+	}
+	return s.Pixels[local], nil
+}
+
+// LocalIndex maps a physical pixel to its index within the section
+func (s *Section) LocalIndex(physical int) (int, bool) {
+	for i, p := range s.Pixels {
+		if p == physical {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Split divides the section into n child sections of nearly equal size,
+// named "<name>-0" through "<name>-<n-1>". Any remainder is spread over the
+// first sections.
+func (s *Section) Split(n int) ([]*Section, error) {
+	if n <= 0 || n > len(s.Pixels) {
+		return nil, fmt.Errorf("cannot split section %s with %d pixels into %d sections", s.Name, len(s.Pixels), n)
+	}
+	size, extra := len(s.Pixels)/n, len(s.Pixels)%n
+	sections := make([]*Section, 0, n)
+	start := 0
+	for i := 0; i < n; i++ {
+		end := start + size
+		if i < extra {
+			end++
+		}
+		pixels := append([]int(nil), s.Pixels[start:end]...)
+		sections = append(sections, NewSection(fmt.Sprintf("%s-%d", s.Name, i), pixels, s.Name))
+		start = end
+	}
+	return sections, nil
+}
+
+// UnionPixels returns the pixels in a followed by those in b that are not in
+// a, without duplicates
+func UnionPixels(a []int, b []int) []int {
+	seen := make(map[int]bool, len(a)+len(b))
+	pixels := make([]int, 0, len(a)+len(b))
+	for _, list := range [][]int{a, b} {
+		for _, p := range list {
+			if !seen[p] {
+				seen[p] = true
+				pixels = append(pixels, p)
+			}
+		}
+	}
+	return pixels
+}
+
+// IntersectPixels returns the pixels in a that are also in b, in the order
+// of a and without duplicates
+func IntersectPixels(a []int, b []int) []int {
+	return filterPixels(a, b, true)
+}
+
+// SubtractPixels returns the pixels in a that are not in b, in the order of
+// a and without duplicates
+func SubtractPixels(a []int, b []int) []int {
+	return filterPixels(a, b, false)
+}
+
+func filterPixels(a []int, b []int, keep bool) []int {
+	inB := make(map[int]bool, len(b))
+	for _, p := range b {
+		inB[p] = true
+	}
+	seen := make(map[int]bool, len(a))
+	pixels := make([]int, 0, len(a))
+	for _, p := range a {
+		if inB[p] == keep && !seen[p] {
+			seen[p] = true
+			pixels = append(pixels, p)
+		}
+	}
+	return pixels
+}
+
+// SectionNode is a section in the hierarchy built by BuildSectionTree
+type SectionNode struct {
+	Section  *Section
+	Parent   *SectionNode
+	Children []*SectionNode
+}
+
+// BuildSectionTree arranges the sections from GetSectionsMap into a tree
+// rooted at the section without a parent (the full strip). Children are
+// sorted by name.
+func BuildSectionTree(sections map[string]*Section) (*SectionNode, error) {
+	nodes := make(map[string]*SectionNode, len(sections))
+	for name, sect := range sections {
+		nodes[name] = &SectionNode{Section: sect}
+	}
+
+	var root *SectionNode
+	for name, node := range nodes {
+		parentName := node.Section.ParentSectionName
+		if parentName == "" {
+			if root != nil {
+				return nil, fmt.Errorf("sections %s and %s both have no parent", root.Section.Name, name)
+			}
+			root = node
+			continue
+		}
+		parent, ok := nodes[parentName]
+		if !ok {
+			return nil, fmt.Errorf("parent section %s of %s not found", parentName, name)
+		}
+		node.Parent = parent
+		parent.Children = append(parent.Children, node)
+	}
+	if root == nil {
+		return nil, errors.New("no root section found")
+	}
+
+	for _, node := range nodes {
+		sort.Slice(node.Children, func(i, j int) bool {
+			return node.Children[i].Section.Name < node.Children[j].Section.Name
+		})
+	}
+
+	// Sections in a parent cycle are unreachable from the root
+	count := 0
+	root.Walk(func(*SectionNode, int) { count++ })
+	if count != len(nodes) {
+		return nil, errors.New("section parents form a cycle")
+	}
+	return root, nil
+}
+
+// Find returns the node for the named section in this subtree, or nil
+func (n *SectionNode) Find(name string) *SectionNode {
+	if n.Section.Name == name {
+		return n
+	}
+	for _, child := range n.Children {
+		if found := child.Find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Walk calls fn for this node and each of its descendants, depth first,
+// with the depth relative to this node
+func (n *SectionNode) Walk(fn func(node *SectionNode, depth int)) {
+	n.walk(fn, 0)
+}
+
+func (n *SectionNode) walk(fn func(node *SectionNode, depth int), depth int) {
+	fn(n, depth)
+	for _, child := range n.Children {
+		child.walk(fn, depth+1)
+	}
+}
+
+// Ancestors returns the chain of sections from this node's parent up to the
+// root
+func (n *SectionNode) Ancestors() []*Section {
+	var chain []*Section
+	for p := n.Parent; p != nil; p = p.Parent {
+		chain = append(chain, p.Section)
+	}
+	return chain
+}
@@ -22,6 +22,113 @@
 
 package animatedledstrip
 
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPixelRange(t *testing.T) {
+	assert.Equal(t, []int{2, 3, 4}, PixelRange(2, 4))
+	assert.Equal(t, []int{4, 3, 2}, PixelRange(4, 2))
+	assert.Equal(t, []int{7}, PixelRange(7, 7))
+
+	sect := NewSectionFromRange("left", 0, 9, "fullStrip")
+	assert.Equal(t, 10, sect.NumLEDs())
+	assert.Equal(t, "fullStrip", sect.ParentSectionName)
+}
+
+func TestSection_Split(t *testing.T) {
+	sect := NewSectionFromRange("strip", 10, 19, "")
+
+	parts, err := sect.Split(3)
+	assert.Nil(t, err)
+	assert.Len(t, parts, 3)
+	assert.Equal(t, NewSection("strip-0", []int{10, 11, 12, 13}, "strip"), parts[0])
+	assert.Equal(t, NewSection("strip-1", []int{14, 15, 16}, "strip"), parts[1])
+	assert.Equal(t, NewSection("strip-2", []int{17, 18, 19}, "strip"), parts[2])
+
+	_, err = sect.Split(0)
+	assert.NotNil(t, err)
+	_, err = sect.Split(11)
+	assert.NotNil(t, err)
+}
+
+func TestPixelSetOperations(t *testing.T) {
+	a := []int{5, 1, 2, 3, 3}
+	b := []int{3, 4, 5}
+
+	assert.Equal(t, []int{5, 1, 2, 3, 4}, UnionPixels(a, b))
+	assert.Equal(t, []int{5, 3}, IntersectPixels(a, b))
+	assert.Equal(t, []int{1, 2}, SubtractPixels(a, b))
+	assert.Equal(t, []int{}, IntersectPixels(a, nil))
+}
+
+func TestSection_Indexes(t *testing.T) {
+	sect := NewSection("reversed", []int{9, 8, 7}, "fullStrip")
+
+	p, err := sect.PhysicalIndex(1)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, p)
+	_, err = sect.PhysicalIndex(3)
+	assert.NotNil(t, err)
+
+	i, ok := sect.LocalIndex(7)
+	assert.True(t, ok)
+	assert.Equal(t, 2, i)
+	_, ok = sect.LocalIndex(0)
+	assert.False(t, ok)
+	assert.True(t, sect.Contains(9))
+	assert.False(t, sect.Contains(10))
+}
+
+func TestBuildSectionTree(t *testing.T) {
+	sections := map[string]*Section{
+		"fullStrip": NewSectionFromRange("fullStrip", 0, 29, ""),
+		"right":     NewSectionFromRange("right", 15, 29, "fullStrip"),
+		"left":      NewSectionFromRange("left", 0, 14, "fullStrip"),
+		"leftEnd":   NewSectionFromRange("leftEnd", 10, 14, "left"),
+	}
+
+	root, err := BuildSectionTree(sections)
+	assert.Nil(t, err)
+	assert.Equal(t, "fullStrip", root.Section.Name)
+	assert.Equal(t, "left", root.Children[0].Section.Name)
+	assert.Equal(t, "right", root.Children[1].Section.Name)
+
+	leftEnd := root.Find("leftEnd")
+	assert.NotNil(t, leftEnd)
+	assert.Equal(t, []*Section{sections["left"], sections["fullStrip"]}, leftEnd.Ancestors())
+	assert.Nil(t, root.Find("missing"))
+
+	var walked []string
+	root.Walk(func(node *SectionNode, depth int) {
+		walked = append(walked, node.Section.Name+":"+string(rune('0'+depth)))
+	})
+	assert.Equal(t, []string{"fullStrip:0", "left:1", "leftEnd:2", "right:1"}, walked)
+}
+
+func TestBuildSectionTree_Errors(t *testing.T) {
+	_, err := BuildSectionTree(map[string]*Section{
+		"fullStrip": NewSection("fullStrip", nil, ""),
+		"orphan":    NewSection("orphan", nil, "missing"),
+	})
+	assert.NotNil(t, err)
+
+	_, err = BuildSectionTree(map[string]*Section{
+		"a": NewSection("a", nil, ""),
+		"b": NewSection("b", nil, ""),
+	})
+	assert.NotNil(t, err)
+
+	_, err = BuildSectionTree(map[string]*Section{
+		"fullStrip": NewSection("fullStrip", nil, ""),
+		"a":         NewSection("a", nil, "b"),
+		"b":         NewSection("b", nil, "a"),
+	})
+	assert.NotNil(t, err)
+}
+
 //func TestSection(t *testing.T) {
 //	sect := Section()
 //
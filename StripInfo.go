@@ -27,7 +27,7 @@ import (
 	"strings"
 )
 
-type stripInfo struct {
+type StripInfo struct {
 	NumLEDs           int  `json:"numLEDs"`
 	Pin               int  `json:"pin"`
 	ImageDebugging    bool `json:"imageDebugging"`
@@ -35,9 +35,9 @@ type stripInfo struct {
 	ThreadCount       int  `json:"threadCount"`
 }
 
-func StripInfoFromJson(data string) (*stripInfo, error) {
+func StripInfoFromJson(data string) (*StripInfo, error) {
 	dataStr := strings.TrimPrefix(data, "SINF:")
-	info := stripInfo{
+	info := StripInfo{
 		NumLEDs:           0,
 		Pin:               -1,
 		ImageDebugging:    false,
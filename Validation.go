@@ -0,0 +1,247 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalid is matched by a *ValidationError
+var ErrInvalid = errors.New("invalid")
+
+// Violation is a single problem found by validation
+type Violation struct {
+	// Field is the JSON name of the offending field, with the map key if any (e.g. "intParams.spacing")
+	Field   string
+	Message string
+	// Warning is set for problems the server tolerates, such as extra colors,
+	// which WithValidation logs instead of rejecting the request
+	Warning bool
+}
+
+func (v *Violation) String() string {
+	if v.Warning {
+		return fmt.Sprintf("%s: warning: %s", v.Field, v.Message)
+	}
+	return fmt.Sprintf("%s: %s", v.Field, v.Message)
+}
+
+// ValidationError is returned when a request is rejected by client-side validation
+type ValidationError struct {
+	Violations []*Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Is allows a *ValidationError to be matched against ErrInvalid with errors.Is
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// FindAnimation returns the animation in animations whose name or abbreviation
+// matches name, ignoring case, or nil if there is none
+func FindAnimation(animations map[string]*AnimationInfo, name string) *AnimationInfo {
+	if info, ok := animations[name]; ok {
+		return info
+	}
+	for _, info := range animations {
+		if strings.EqualFold(info.Name, name) || strings.EqualFold(info.Abbr, name) {
+			return info
+		}
+	}
+	return nil
+}
+
+func unknownParams(field string, keys []string, params []*AnimationParameter) []*Violation {
+	known := make(map[string]bool, len(params))
+	for _, param := range params {
+		known[param.Name] = true
+	}
+	sort.Strings(keys)
+	var violations []*Violation
+	for _, key := range keys {
+		if !known[key] {
+			violations = append(violations, &Violation{
+				Field:   field + "." + key,
+				Message: "unknown parameter",
+			})
+		}
+	}
+	return violations
+}
+
+// Validate checks p against the animation's info, returning every violation found
+// or nil if p is valid
+func (p *AnimationToRunParams) Validate(info *AnimationInfo) []*Violation {
+	var violations []*Violation
+	if !strings.EqualFold(p.Animation, info.Name) && !strings.EqualFold(p.Animation, info.Abbr) {
+		violations = append(violations, &Violation{
+			Field:   "animation",
+			Message: fmt.Sprintf("%q does not match %s", p.Animation, info.Name),
+		})
+	}
+
+	if len(p.Colors) < info.MinimumColors {
+		violations = append(violations, &Violation{
+			Field:   "colors",
+			Message: fmt.Sprintf("%s requires at least %d colors, got %d", info.Name, info.MinimumColors, len(p.Colors)),
+		})
+	} else if !info.UnlimitedColors && len(p.Colors) > info.MinimumColors {
+		// The server ignores the extra colors rather than rejecting them
+		violations = append(violations, &Violation{
+			Field:   "colors",
+			Message: fmt.Sprintf("%s only uses %d colors, got %d", info.Name, info.MinimumColors, len(p.Colors)),
+			Warning: true,
+		})
+	}
+	for i, color := range p.Colors {
+		if color == nil || len(color.Colors) == 0 {
+			violations = append(violations, &Violation{
+				Field:   fmt.Sprintf("colors.%d", i),
+				Message: "color container is empty",
+			})
+		}
+	}
+
+	if p.RunCount < -1 {
+		violations = append(violations, &Violation{
+			Field:   "runCount",
+			Message: fmt.Sprintf("must be -1 (run until ended), 0 (default) or positive, got %d", p.RunCount),
+		})
+	}
+
+	var keys []string
+	for key := range p.IntParams {
+		keys = append(keys, key)
+	}
+	violations = append(violations, unknownParams("intParams", keys, info.IntParams)...)
+	keys = nil
+	for key := range p.DoubleParams {
+		keys = append(keys, key)
+	}
+	violations = append(violations, unknownParams("doubleParams", keys, info.DoubleParams)...)
+	keys = nil
+	for key := range p.StringParams {
+		keys = append(keys, key)
+	}
+	violations = append(violations, unknownParams("stringParams", keys, info.StringParams)...)
+	keys = nil
+	for key := range p.LocationParams {
+		keys = append(keys, key)
+	}
+	violations = append(violations, unknownParams("locationParams", keys, info.LocationParams)...)
+	keys = nil
+	for key := range p.DistanceParams {
+		keys = append(keys, key)
+	}
+	violations = append(violations, unknownParams("distanceParams", keys, info.DistanceParams)...)
+	keys = nil
+	for key := range p.RotationParams {
+		keys = append(keys, key)
+	}
+	violations = append(violations, unknownParams("rotationParams", keys, info.RotationParams)...)
+	keys = nil
+	for key := range p.EquationParams {
+		keys = append(keys, key)
+	}
+	violations = append(violations, unknownParams("equationParams", keys, info.EquationParams)...)
+
+	return violations
+}
+
+// ValidateAgainst finds p's animation in animations by name or abbreviation and validates p against it
+func (p *AnimationToRunParams) ValidateAgainst(animations map[string]*AnimationInfo) []*Violation {
+	info := FindAnimation(animations, p.Animation)
+	if info == nil {
+		return []*Violation{{Field: "animation", Message: fmt.Sprintf("unknown animation %q", p.Animation)}}
+	}
+	return p.Validate(info)
+}
+
+// Validate checks s against the strip and the existing sections before it is
+// created, returning every violation found or nil if s is valid. A section
+// without a ParentSectionName is checked against the section that has no
+// parent (the full strip), which the server uses as the default parent.
+func (s *Section) Validate(stripInfo *StripInfo, sections map[string]*Section) []*Violation {
+	var violations []*Violation
+	if s.Name == "" {
+		violations = append(violations, &Violation{Field: "name", Message: "must not be empty"})
+	} else if _, ok := sections[s.Name]; ok {
+		violations = append(violations, &Violation{
+			Field:   "name",
+			Message: fmt.Sprintf("section %s already exists", s.Name),
+		})
+	}
+
+	var parent *Section
+	if s.ParentSectionName == "" {
+		for _, sect := range sections {
+			if sect.ParentSectionName == "" {
+				parent = sect
+				break
+			}
+		}
+	} else if parent = sections[s.ParentSectionName]; parent == nil {
+		violations = append(violations, &Violation{
+			Field:   "parentSectionName",
+			Message: fmt.Sprintf("section %s does not exist", s.ParentSectionName),
+		})
+	}
+
+	if len(s.Pixels) == 0 {
+		violations = append(violations, &Violation{Field: "pixels", Message: "must not be empty"})
+	}
+	seen := make(map[int]bool, len(s.Pixels))
+	for i, pixel := range s.Pixels {
+		field := fmt.Sprintf("pixels.%d", i)
+		switch {
+		case stripInfo != nil && (pixel < 0 || pixel >= stripInfo.NumLEDs):
+			violations = append(violations, &Violation{
+				Field:   field,
+				Message: fmt.Sprintf("pixel %d is outside the strip's %d LEDs", pixel, stripInfo.NumLEDs),
+			})
+		case seen[pixel]:
+			violations = append(violations, &Violation{
+				Field:   field,
+				Message: fmt.Sprintf("pixel %d is duplicated", pixel),
+			})
+		case parent != nil && !parent.Contains(pixel):
+			violations = append(violations, &Violation{
+				Field:   field,
+				Message: fmt.Sprintf("pixel %d is not in parent section %s", pixel, parent.Name),
+			})
+		}
+		seen[pixel] = true
+	}
+
+	return violations
+}
@@ -0,0 +1,215 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package animatedledstrip
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func meteorInfo() *AnimationInfo {
+	return &AnimationInfo{
+		Name:            "Meteor",
+		Abbr:            "MET",
+		MinimumColors:   1,
+		UnlimitedColors: false,
+		IntParams:       []*AnimationParameter{{Name: "spacing"}},
+		DoubleParams:    []*AnimationParameter{{Name: "speed"}},
+		LocationParams:  []*AnimationParameter{{Name: "center"}},
+	}
+}
+
+func TestAnimationToRunParams_ValidateValid(t *testing.T) {
+	p := &AnimationToRunParams{
+		Animation:      "met",
+		Colors:         []*ColorContainer{NewColorContainer([]int{0xFF})},
+		RunCount:       -1,
+		IntParams:      map[string]int{"spacing": 3},
+		LocationParams: map[string]*Location{"center": NewLocation(0, 0, 0)},
+	}
+
+	assert.Len(t, p.Validate(meteorInfo()), 0)
+}
+
+func TestAnimationToRunParams_ValidateViolations(t *testing.T) {
+	p := &AnimationToRunParams{
+		Animation:      "Meteor",
+		Colors:         []*ColorContainer{NewColorContainer([]int{0xFF}), NewColorContainer(nil)},
+		RunCount:       -2,
+		IntParams:      map[string]int{"spacing": 3, "spaceing": 3},
+		DoubleParams:   map[string]float64{"sped": 1},
+		StringParams:   map[string]string{"text": ""},
+		DistanceParams: map[string]*Distance{"distance": AbsoluteDistance(1, 0, 0)},
+		RotationParams: map[string]*Rotation{"rotation": DegreesRotation(0, 0, 0, nil)},
+		EquationParams: map[string]*Equation{"eq": NewEquation(nil)},
+	}
+
+	var fields []string
+	for _, v := range p.Validate(meteorInfo()) {
+		fields = append(fields, v.Field)
+	}
+	assert.Equal(t, []string{
+		"colors", "colors.1", "runCount", "intParams.spaceing", "doubleParams.sped", "stringParams.text",
+		"distanceParams.distance", "rotationParams.rotation", "equationParams.eq",
+	}, fields)
+}
+
+func TestAnimationToRunParams_ValidateColors(t *testing.T) {
+	info := &AnimationInfo{Name: "Alternate", MinimumColors: 2, UnlimitedColors: true}
+	p := &AnimationToRunParams{Animation: "Alternate", Colors: []*ColorContainer{NewColorContainer([]int{1})}}
+
+	violations := p.Validate(info)
+	assert.Len(t, violations, 1)
+	assert.Equal(t, "colors: Alternate requires at least 2 colors, got 1", violations[0].String())
+
+	p.Colors = append(p.Colors, NewColorContainer([]int{2}), NewColorContainer([]int{3}))
+	assert.Len(t, p.Validate(info), 0)
+
+	// Extra colors on a limited animation are only a warning
+	info.UnlimitedColors = false
+	violations = p.Validate(info)
+	assert.Len(t, violations, 1)
+	assert.True(t, violations[0].Warning)
+	assert.Equal(t, "colors: warning: Alternate only uses 2 colors, got 3", violations[0].String())
+}
+
+func TestAnimationToRunParams_ValidateAgainst(t *testing.T) {
+	animations := map[string]*AnimationInfo{"Meteor": meteorInfo()}
+
+	p := &AnimationToRunParams{Animation: "MET", Colors: []*ColorContainer{NewColorContainer([]int{1})}}
+	assert.Len(t, p.ValidateAgainst(animations), 0)
+
+	p.Animation = "Metor"
+	violations := p.ValidateAgainst(animations)
+	assert.Len(t, violations, 1)
+	assert.Equal(t, "animation", violations[0].Field)
+}
+
+func TestALSHttpClient_WithValidation(t *testing.T) {
+	var mapRequests, startRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/animations/map":
+			atomic.AddInt32(&mapRequests, 1)
+			_, _ = w.Write([]byte(`{"Meteor":{"name":"Meteor","abbr":"MET","minimumColors":1,"intParams":[{"name":"spacing"}]}}`))
+		case "/start":
+			atomic.AddInt32(&startRequests, 1)
+			_, _ = w.Write([]byte(`{"id":"1","animationName":"Meteor"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := ALSHttpClient("", WithBaseURL(server.URL), WithValidation())
+
+	_, err := c.StartAnimation(&AnimationToRunParams{Animation: "Meteor", IntParams: map[string]int{"spaceing": 1}})
+	assert.True(t, errors.Is(err, ErrInvalid))
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Violations, 2)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&startRequests))
+
+	params, err := c.StartAnimation(&AnimationToRunParams{Animation: "MET", Colors: []*ColorContainer{NewColorContainer([]int{1})}})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", params.Id)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&mapRequests))
+
+	// Extra colors are only a warning, so the animation is still started
+	_, err = c.StartAnimation(&AnimationToRunParams{Animation: "MET",
+		Colors: []*ColorContainer{NewColorContainer([]int{1}), NewColorContainer([]int{2})}})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&startRequests))
+
+	// An unknown animation refreshes the cache before failing
+	_, err = c.StartAnimation(&AnimationToRunParams{Animation: "Ripple"})
+	assert.True(t, errors.Is(err, ErrInvalid))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&mapRequests))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&startRequests))
+}
+
+func testSections() map[string]*Section {
+	return map[string]*Section{
+		"fullStrip": NewSectionFromRange("fullStrip", 0, 29, ""),
+		"left":      NewSectionFromRange("left", 0, 14, "fullStrip"),
+	}
+}
+
+func TestSection_ValidateValid(t *testing.T) {
+	info := &StripInfo{NumLEDs: 30}
+
+	assert.Len(t, NewSectionFromRange("leftEnd", 10, 14, "left").Validate(info, testSections()), 0)
+	assert.Len(t, NewSectionFromRange("right", 15, 29, "").Validate(info, testSections()), 0)
+}
+
+func TestSection_ValidateViolations(t *testing.T) {
+	info := &StripInfo{NumLEDs: 30}
+
+	violations := NewSection("left", []int{14, 15, 14, 30}, "left").Validate(info, testSections())
+	var msgs []string
+	for _, v := range violations {
+		msgs = append(msgs, v.String())
+	}
+	assert.Equal(t, []string{
+		"name: section left already exists",
+		"pixels.1: pixel 15 is not in parent section left",
+		"pixels.2: pixel 14 is duplicated",
+		"pixels.3: pixel 30 is outside the strip's 30 LEDs",
+	}, msgs)
+
+	violations = NewSection("", nil, "missing").Validate(info, testSections())
+	assert.Len(t, violations, 3)
+	assert.Equal(t, "name", violations[0].Field)
+	assert.Equal(t, "parentSectionName", violations[1].Field)
+	assert.Equal(t, "pixels", violations[2].Field)
+}
+
+func TestALSHttpClient_WithValidationSection(t *testing.T) {
+	var createRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/strip/info":
+			_, _ = w.Write([]byte(`{"numLEDs":30}`))
+		case "/sections/map":
+			_, _ = w.Write([]byte(`{"fullStrip":{"name":"fullStrip","pixels":[0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21,22,23,24,25,26,27,28,29],"parentSectionName":""}}`))
+		case "/sections":
+			atomic.AddInt32(&createRequests, 1)
+			_, _ = w.Write([]byte(`{"name":"left","pixels":[0,1],"parentSectionName":"fullStrip"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := ALSHttpClient("", WithBaseURL(server.URL), WithValidation())
+
+	_, err := c.CreateNewSection(NewSectionFromRange("left", 25, 35, ""))
+	assert.True(t, errors.Is(err, ErrInvalid))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&createRequests))
+
+	sect, err := c.CreateNewSection(NewSectionFromRange("left", 0, 1, ""))
+	assert.Nil(t, err)
+	assert.Equal(t, "left", sect.Name)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&createRequests))
+}
@@ -0,0 +1,354 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+// Package alstest provides an in-process stand-in for an AnimatedLEDStrip server
+// built on net/http/httptest, for testing code that uses the HTTP client
+package alstest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/fake"
+)
+
+// Fault describes a failure injected into requests matching Method and Path
+type Fault struct {
+	// Method to match; empty matches any method
+	Method string
+	// Path to match exactly (e.g. "/strip/info")
+	Path string
+	// StatusCode to respond with; 0 responds normally after Latency
+	StatusCode int
+	// Body to respond with along with StatusCode
+	Body string
+	// Malformed responds with 200 and a body that is not valid JSON
+	Malformed bool
+	// Latency is added before responding
+	Latency time.Duration
+	// Times is the number of requests to affect; 0 affects every request until ClearFaults is called
+	Times int
+}
+
+type config struct {
+	numLEDs       int
+	animations    []*als.AnimationInfo
+	sections      []*als.Section
+	disableEvents bool
+}
+
+// Option configures a Server created by NewServer
+type Option func(*config)
+
+// WithNumLEDs sets the number of LEDs in the strip (default 240)
+func WithNumLEDs(numLEDs int) Option {
+	return func(c *config) {
+		c.numLEDs = numLEDs
+	}
+}
+
+// WithAnimations sets the animations the server supports
+func WithAnimations(animations ...*als.AnimationInfo) Option {
+	return func(c *config) {
+		c.animations = append(c.animations, animations...)
+	}
+}
+
+// WithSections adds sections in addition to the fullStrip section
+func WithSections(sections ...*als.Section) Option {
+	return func(c *config) {
+		c.sections = append(c.sections, sections...)
+	}
+}
+
+// WithoutEventStream disables the /events endpoint, as on servers that do not support it
+func WithoutEventStream() Option {
+	return func(c *config) {
+		c.disableEvents = true
+	}
+}
+
+// Server emulates the REST API of an AnimatedLEDStrip server.
+// Its state is kept in State, which can be inspected and modified directly.
+type Server struct {
+	*httptest.Server
+	State *fake.Client
+
+	disableEvents bool
+
+	mu       sync.Mutex
+	latency  time.Duration
+	faults   []*Fault
+	requests []string
+	// received is closed and replaced whenever a request is received
+	received chan struct{}
+}
+
+// NewServer starts a Server; it should be closed with Close when no longer needed
+func NewServer(opts ...Option) *Server {
+	conf := &config{numLEDs: 240}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	s := &Server{
+		State:         fake.NewClient(conf.numLEDs, conf.animations...),
+		disableEvents: conf.disableEvents,
+		received:      make(chan struct{}),
+	}
+	for _, sect := range conf.sections {
+		_, err := s.State.CreateNewSection(sect)
+		if err != nil {
+			panic("alstest: invalid section " + sect.Name + ": " + err.Error())
+		}
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns an HTTP client connected to the server
+func (s *Server) Client(opts ...als.ClientOption) als.Client {
+	return als.ALSHttpClient("", append([]als.ClientOption{als.WithBaseURL(s.URL)}, opts...)...)
+}
+
+// SetLatency adds a delay before every response
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// AddFault injects a fault into matching requests
+func (s *Server) AddFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// InjectError makes every matching request fail with statusCode and body
+func (s *Server) InjectError(method string, path string, statusCode int, body string) {
+	s.AddFault(Fault{Method: method, Path: path, StatusCode: statusCode, Body: body})
+}
+
+// InjectMalformed makes every matching request respond with a body that is not valid JSON
+func (s *Server) InjectMalformed(method string, path string) {
+	s.AddFault(Fault{Method: method, Path: path, Malformed: true})
+}
+
+// ClearFaults removes every injected fault and the added latency
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+	s.latency = 0
+}
+
+// Requests returns every request received, as "METHOD /path"
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// WaitForRequest waits until the server has received a request matching
+// request, given as "METHOD /path", returning false if timeout passes first
+func (s *Server) WaitForRequest(request string, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		s.mu.Lock()
+		for _, r := range s.requests {
+			if r == request {
+				s.mu.Unlock()
+				return true
+			}
+		}
+		received := s.received
+		s.mu.Unlock()
+
+		select {
+		case <-received:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+func (s *Server) matchFault(r *http.Request) (*Fault, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	close(s.received)
+	s.received = make(chan struct{})
+	for i, fault := range s.faults {
+		if fault.Path != r.URL.Path || (fault.Method != "" && fault.Method != r.Method) {
+			continue
+		}
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return fault, s.latency
+	}
+	return nil, s.latency
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	fault, latency := s.matchFault(r)
+	if fault != nil {
+		latency += fault.Latency
+	}
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if fault != nil {
+		if fault.Malformed {
+			_, _ = w.Write([]byte(`{"malformed":`))
+			return
+		} else if fault.StatusCode != 0 {
+			w.WriteHeader(fault.StatusCode)
+			_, _ = w.Write([]byte(fault.Body))
+			return
+		}
+	}
+	s.route(w, r)
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodGet && path == "/animations/names":
+		respond(w)(s.State.GetSupportedAnimationsNamesContext(ctx))
+	case r.Method == http.MethodGet && path == "/animations":
+		respond(w)(s.State.GetSupportedAnimationsContext(ctx))
+	case r.Method == http.MethodGet && path == "/animations/map":
+		respond(w)(s.State.GetSupportedAnimationsMapContext(ctx))
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/animation/"):
+		respond(w)(s.State.GetAnimationInfoContext(ctx, strings.TrimPrefix(path, "/animation/")))
+	case r.Method == http.MethodGet && path == "/running":
+		respond(w)(s.State.GetRunningAnimationsContext(ctx))
+	case r.Method == http.MethodGet && path == "/running/ids":
+		respond(w)(s.State.GetRunningAnimationsIdsContext(ctx))
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/running/"):
+		respond(w)(s.State.GetRunningAnimationParamsContext(ctx, strings.TrimPrefix(path, "/running/")))
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/running/"):
+		respond(w)(s.State.EndAnimationContext(ctx, strings.TrimPrefix(path, "/running/")))
+	case r.Method == http.MethodGet && path == "/sections":
+		respond(w)(s.State.GetSectionsContext(ctx))
+	case r.Method == http.MethodPost && path == "/sections":
+		var newSection als.Section
+		if !decodeRequest(w, r, &newSection) {
+			return
+		}
+		respond(w)(s.State.CreateNewSectionContext(ctx, &newSection))
+	case r.Method == http.MethodGet && path == "/sections/map":
+		respond(w)(s.State.GetSectionsMapContext(ctx))
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/section/"):
+		respond(w)(s.State.GetSectionContext(ctx, strings.TrimPrefix(path, "/section/")))
+	case r.Method == http.MethodPost && path == "/start":
+		var newAnim als.AnimationToRunParams
+		if !decodeRequest(w, r, &newAnim) {
+			return
+		}
+		respond(w)(s.State.StartAnimationContext(ctx, &newAnim))
+	case r.Method == http.MethodGet && path == "/strip/info":
+		respond(w)(s.State.GetStripInfoContext(ctx))
+	case r.Method == http.MethodGet && path == "/strip/color":
+		respond(w)(s.State.GetCurrentStripColorContext(ctx))
+	case r.Method == http.MethodGet && path == "/strip/clear":
+		respond(w)(nil, s.State.ClearStripContext(ctx))
+	case r.Method == http.MethodGet && path == "/events" && !s.disableEvents:
+		s.streamEvents(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// streamEvents sends state changes as server-sent events until the client disconnects
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	events, err := s.State.Subscribe(r.Context(), nil)
+	if err != nil {
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	body, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, v)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func respond(w http.ResponseWriter) func(interface{}, error) {
+	return func(v interface{}, err error) {
+		if err != nil {
+			var apiErr *als.APIError
+			if errors.As(err, &apiErr) {
+				http.Error(w, apiErr.Body, apiErr.StatusCode)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+}
@@ -0,0 +1,490 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/preview"
+)
+
+func (a *app) listAnimations(ctx context.Context, args []string) error {
+	if _, err := a.parseFlags(flag.NewFlagSet("animations list", flag.ContinueOnError), args, 0, ""); err != nil {
+		return err
+	}
+	animations, err := a.client.GetSupportedAnimationsContext(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(animations, func(i, j int) bool { return animations[i].Name < animations[j].Name })
+	if a.output == "json" {
+		return a.printJSON(animations)
+	}
+	rows := make([][]string, 0, len(animations))
+	for _, info := range animations {
+		colors := strconv.Itoa(info.MinimumColors)
+		if info.UnlimitedColors {
+			colors += "+"
+		}
+		rows = append(rows, []string{info.Name, info.Abbr, colors, strings.Join(info.Dimensionality, ","), info.Description})
+	}
+	return a.printTable([]string{"NAME", "ABBR", "COLORS", "DIMENSIONS", "DESCRIPTION"}, rows)
+}
+
+func (a *app) describeAnimation(ctx context.Context, args []string) error {
+	names, err := a.parseFlags(flag.NewFlagSet("animations describe", flag.ContinueOnError), args, 1, "NAME")
+	if err != nil {
+		return err
+	}
+	info, err := a.client.GetAnimationInfoContext(ctx, names[0])
+	if err != nil {
+		return err
+	}
+	if a.output == "json" {
+		return a.printJSON(info)
+	}
+	colors := strconv.Itoa(info.MinimumColors)
+	if info.UnlimitedColors {
+		colors += " or more"
+	}
+	err = a.printFields([][]string{
+		{"Name", info.Name},
+		{"Abbr", info.Abbr},
+		{"Description", info.Description},
+		{"Colors", colors},
+		{"Run count default", strconv.Itoa(info.RunCountDefault)},
+		{"Dimensionality", strings.Join(info.Dimensionality, ", ")},
+	})
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	lists := []struct {
+		kind   string
+		params []*als.AnimationParameter
+	}{
+		{"int", info.IntParams},
+		{"double", info.DoubleParams},
+		{"string", info.StringParams},
+		{"location", info.LocationParams},
+		{"distance", info.DistanceParams},
+		{"rotation", info.RotationParams},
+		{"equation", info.EquationParams},
+	}
+	for _, list := range lists {
+		for _, p := range list.params {
+			def := formatValue(p.Default)
+			if p.DefaultError() != nil {
+				// Show a default that couldn't be decoded as the server sent it
+				def = string(p.RawDefault()) + " (invalid)"
+			}
+			rows = append(rows, []string{list.kind, p.Name, def, p.Description})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	fmt.Fprintln(a.stdout)
+	return a.printTable([]string{"TYPE", "PARAMETER", "DEFAULT", "DESCRIPTION"}, rows)
+}
+
+func (a *app) start(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("start", flag.ContinueOnError)
+	var colors, ints, doubles, strs, locations, distances, rotations, equations multiFlag
+	fs.Var(&colors, "color", "a color container as comma-separated colors, e.g. red,#00FF00,rgb(0,0,255); repeatable")
+	section := fs.String("section", "", "section to run on (default fullStrip)")
+	id := fs.String("id", "", "id for the animation (default assigned by the server)")
+	runCount := fs.Int("run-count", 0, "times to run; -1 runs until ended, 0 uses the animation's default")
+	fs.Var(&ints, "int", "int parameter NAME=VALUE; repeatable")
+	fs.Var(&doubles, "double", "double parameter NAME=VALUE; repeatable")
+	fs.Var(&strs, "string", "string parameter NAME=VALUE; repeatable")
+	fs.Var(&locations, "location", "location parameter NAME=X,Y,Z; repeatable")
+	fs.Var(&distances, "distance", "distance parameter NAME=X,Y,Z[,percent]; repeatable")
+	fs.Var(&rotations, "rotation", "rotation parameter NAME=X,Y,Z[,radians][,ORDER...] in degrees unless radians; repeatable")
+	fs.Var(&equations, "equation", "equation parameter NAME=C0,C1,...; repeatable")
+	names, err := a.parseFlags(fs, args, 1, "NAME [flags]")
+	if err != nil {
+		return err
+	}
+
+	b := als.NewAnimation(names[0]).Section(*section).Id(*id).RunCount(*runCount)
+	for _, c := range colors {
+		cc, err := parseColorContainer(c)
+		if err != nil {
+			return err
+		}
+		b.Colors(cc)
+	}
+	for _, p := range ints {
+		name, value, err := splitParam("int", p)
+		if err != nil {
+			return err
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("--int %s: %q is not an integer", name, value)
+		}
+		b.Int(name, v)
+	}
+	for _, p := range doubles {
+		name, value, err := splitParam("double", p)
+		if err != nil {
+			return err
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("--double %s: %q is not a number", name, value)
+		}
+		b.Double(name, v)
+	}
+	for _, p := range strs {
+		name, value, err := splitParam("string", p)
+		if err != nil {
+			return err
+		}
+		b.String(name, value)
+	}
+	for _, p := range locations {
+		name, value, err := splitParam("location", p)
+		if err != nil {
+			return err
+		}
+		v, err := parseLocation(value)
+		if err != nil {
+			return fmt.Errorf("--location %s: %w", name, err)
+		}
+		b.Location(name, v)
+	}
+	for _, p := range distances {
+		name, value, err := splitParam("distance", p)
+		if err != nil {
+			return err
+		}
+		v, err := parseDistance(value)
+		if err != nil {
+			return fmt.Errorf("--distance %s: %w", name, err)
+		}
+		b.Distance(name, v)
+	}
+	for _, p := range rotations {
+		name, value, err := splitParam("rotation", p)
+		if err != nil {
+			return err
+		}
+		v, err := parseRotation(value)
+		if err != nil {
+			return fmt.Errorf("--rotation %s: %w", name, err)
+		}
+		b.Rotation(name, v)
+	}
+	for _, p := range equations {
+		name, value, err := splitParam("equation", p)
+		if err != nil {
+			return err
+		}
+		v, err := parseEquation(value)
+		if err != nil {
+			return fmt.Errorf("--equation %s: %w", name, err)
+		}
+		b.Equation(name, v)
+	}
+
+	running, err := a.client.StartAnimationContext(ctx, b.Build())
+	if err != nil {
+		return err
+	}
+	return a.printRunning(running)
+}
+
+func (a *app) listRunning(ctx context.Context, args []string) error {
+	if _, err := a.parseFlags(flag.NewFlagSet("running list", flag.ContinueOnError), args, 0, ""); err != nil {
+		return err
+	}
+	running, err := a.client.GetRunningAnimationsContext(ctx)
+	if err != nil {
+		return err
+	}
+	if a.output == "json" {
+		return a.printJSON(running)
+	}
+	rows := make([][]string, 0, len(running))
+	for _, id := range sortedKeys(running) {
+		p := running[id]
+		rows = append(rows, []string{p.Id, p.AnimationName, p.Section, strconv.Itoa(p.RunCount), formatPreparedColors(p.Colors)})
+	}
+	return a.printTable([]string{"ID", "ANIMATION", "SECTION", "RUN COUNT", "COLORS"}, rows)
+}
+
+func (a *app) describeRunning(ctx context.Context, args []string) error {
+	ids, err := a.parseFlags(flag.NewFlagSet("running describe", flag.ContinueOnError), args, 1, "ID")
+	if err != nil {
+		return err
+	}
+	running, err := a.client.GetRunningAnimationParamsContext(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+	return a.printRunning(running)
+}
+
+func (a *app) printRunning(p *als.RunningAnimationParams) error {
+	if a.output == "json" {
+		return a.printJSON(p)
+	}
+	err := a.printFields([][]string{
+		{"Id", p.Id},
+		{"Animation", p.AnimationName},
+		{"Section", p.Section},
+		{"Run count", strconv.Itoa(p.RunCount)},
+		{"Colors", formatPreparedColors(p.Colors)},
+	})
+	if err != nil {
+		return err
+	}
+	rows := paramRows(p)
+	if len(rows) == 0 {
+		return nil
+	}
+	fmt.Fprintln(a.stdout)
+	return a.printTable([]string{"TYPE", "PARAMETER", "VALUE"}, rows)
+}
+
+func (a *app) end(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("end", flag.ContinueOnError)
+	all := fs.Bool("all", false, "end every running animation")
+	ids, err := a.parseFlags(fs, args, anyNumber, "ID... | --all")
+	if err != nil {
+		return err
+	}
+	if *all == (len(ids) > 0) {
+		fs.Usage()
+		return errUsage
+	}
+	if *all {
+		if ids, err = a.client.GetRunningAnimationsIdsContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	ended := make([]*als.RunningAnimationParams, 0, len(ids))
+	for _, id := range ids {
+		p, err := a.client.EndAnimationContext(ctx, id)
+		if err != nil {
+			return fmt.Errorf("ending %s: %w", id, err)
+		}
+		ended = append(ended, p)
+	}
+	if a.output == "json" {
+		return a.printJSON(ended)
+	}
+	rows := make([][]string, 0, len(ended))
+	for _, p := range ended {
+		rows = append(rows, []string{p.Id, p.AnimationName, p.Section})
+	}
+	return a.printTable([]string{"ENDED", "ANIMATION", "SECTION"}, rows)
+}
+
+func (a *app) listSections(ctx context.Context, args []string) error {
+	if _, err := a.parseFlags(flag.NewFlagSet("sections list", flag.ContinueOnError), args, 0, ""); err != nil {
+		return err
+	}
+	sections, err := a.client.GetSectionsContext(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].Name < sections[j].Name })
+	if a.output == "json" {
+		return a.printJSON(sections)
+	}
+	rows := make([][]string, 0, len(sections))
+	for _, s := range sections {
+		rows = append(rows, []string{s.Name, s.ParentSectionName, strconv.Itoa(s.NumLEDs()), formatPixels(s.Pixels)})
+	}
+	return a.printTable([]string{"NAME", "PARENT", "LEDS", "PIXELS"}, rows)
+}
+
+func (a *app) describeSection(ctx context.Context, args []string) error {
+	names, err := a.parseFlags(flag.NewFlagSet("sections describe", flag.ContinueOnError), args, 1, "NAME")
+	if err != nil {
+		return err
+	}
+	sect, err := a.client.GetSectionContext(ctx, names[0])
+	if err != nil {
+		return err
+	}
+	return a.printSection(sect)
+}
+
+func (a *app) createSection(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sections create", flag.ContinueOnError)
+	pixels := fs.String("pixels", "", "pixels and inclusive ranges, e.g. 0-9,20,25-29")
+	parent := fs.String("parent", "", "parent section (default fullStrip)")
+	names, err := a.parseFlags(fs, args, 1, "NAME --pixels PIXELS [--parent PARENT]")
+	if err != nil {
+		return err
+	}
+	if *pixels == "" {
+		return a.usageError("sections create needs --pixels")
+	}
+	p, err := parsePixels(*pixels)
+	if err != nil {
+		return err
+	}
+	sect, err := a.client.CreateNewSectionContext(ctx, als.NewSection(names[0], p, *parent))
+	if err != nil {
+		return err
+	}
+	return a.printSection(sect)
+}
+
+func (a *app) printSection(s *als.Section) error {
+	if a.output == "json" {
+		return a.printJSON(s)
+	}
+	return a.printFields([][]string{
+		{"Name", s.Name},
+		{"Parent", s.ParentSectionName},
+		{"LEDs", strconv.Itoa(s.NumLEDs())},
+		{"Pixels", formatPixels(s.Pixels)},
+	})
+}
+
+func (a *app) stripInfo(ctx context.Context, args []string) error {
+	if _, err := a.parseFlags(flag.NewFlagSet("strip info", flag.ContinueOnError), args, 0, ""); err != nil {
+		return err
+	}
+	info, err := a.client.GetStripInfoContext(ctx)
+	if err != nil {
+		return err
+	}
+	if a.output == "json" {
+		return a.printJSON(info)
+	}
+	return a.printFields([][]string{
+		{"LEDs", strconv.Itoa(info.NumLEDs)},
+		{"Pin", strconv.Itoa(info.Pin)},
+		{"Image debugging", strconv.FormatBool(info.ImageDebugging)},
+		{"Renders before save", strconv.Itoa(info.RendersBeforeSave)},
+		{"Thread count", strconv.Itoa(info.ThreadCount)},
+	})
+}
+
+func (a *app) stripColor(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("strip color", flag.ContinueOnError)
+	ansi := fs.Bool("ansi", false, "show the strip as a colored bar in the terminal")
+	pngFile := fs.String("png", "", "write the strip as a PNG swatch to this file")
+	bySection := fs.Bool("sections", false, "show one row per section with --ansi or --png")
+	if _, err := a.parseFlags(fs, args, 0, "[--ansi] [--png FILE] [--sections]"); err != nil {
+		return err
+	}
+	colors, err := a.client.GetCurrentStripColorContext(ctx)
+	if err != nil {
+		return err
+	}
+	if *ansi || *pngFile != "" {
+		opts := &preview.Options{}
+		if *bySection {
+			if opts.Sections, err = a.client.GetSectionsMapContext(ctx); err != nil {
+				return err
+			}
+		}
+		if *pngFile != "" {
+			f, err := os.Create(*pngFile)
+			if err != nil {
+				return err
+			}
+			if err = preview.PNG(f, colors, opts); err != nil {
+				f.Close()
+				return err
+			}
+			if err = f.Close(); err != nil {
+				return err
+			}
+		}
+		if *ansi {
+			fmt.Fprint(a.stdout, preview.ANSI(colors, opts))
+		}
+		return nil
+	}
+	if a.output == "json" {
+		return a.printJSON(colors)
+	}
+	rows := make([][]string, len(colors))
+	for i, c := range colors {
+		rows[i] = []string{strconv.Itoa(i), als.ColorHex(c)}
+	}
+	return a.printTable([]string{"PIXEL", "COLOR"}, rows)
+}
+
+func (a *app) clear(ctx context.Context, args []string) error {
+	if _, err := a.parseFlags(flag.NewFlagSet("clear", flag.ContinueOnError), args, 0, ""); err != nil {
+		return err
+	}
+	if err := a.client.ClearStripContext(ctx); err != nil {
+		return err
+	}
+	if a.output == "json" {
+		return a.printJSON(map[string]bool{"cleared": true})
+	}
+	fmt.Fprintln(a.stdout, "Strip cleared")
+	return nil
+}
+
+func (a *app) apply(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the plan without applying it")
+	files, err := a.parseFlags(fs, args, 1, "FILE [--dry-run]")
+	if err != nil {
+		return err
+	}
+	desired, err := als.LoadDesiredState(files[0])
+	if err != nil {
+		return err
+	}
+	plan, applyErr := als.NewReconciler(a.client).Reconcile(ctx, desired, *dryRun)
+	if plan == nil {
+		return applyErr
+	}
+
+	if a.output == "json" {
+		actions := make([]string, len(plan.Actions))
+		for i, action := range plan.Actions {
+			actions[i] = action.String()
+		}
+		err = a.printJSON(map[string]interface{}{"actions": actions, "conflicts": plan.Conflicts, "applied": !*dryRun && applyErr == nil})
+	} else {
+		_, err = fmt.Fprintln(a.stdout, plan)
+	}
+	if applyErr != nil {
+		return applyErr
+	}
+	return err
+}
@@ -0,0 +1,278 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+// Command alsctl controls an AnimatedLEDStrip server from the command line.
+//
+// Usage:
+//
+//	alsctl [--server HOST[:PORT]|URL] [--output table|json] COMMAND [ARGS]
+//
+// The server can also be set with the ALS_SERVER environment variable.
+// Run alsctl help for the list of commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+)
+
+const usage = `Usage: alsctl [flags] COMMAND [ARGS]
+
+Commands:
+  animations [list]                 List supported animations
+  animations describe NAME          Show an animation's parameters
+  start NAME [flags]                Start an animation (see alsctl start --help)
+  running [list]                    List running animations
+  running describe ID               Show a running animation
+  end ID... | end --all             End running animations
+  sections [list]                   List sections
+  sections describe NAME            Show a section
+  sections create NAME --pixels P   Create a section (P like 0-9,20,25-29)
+  strip info                        Show strip info
+  strip color [--ansi|--png FILE]   Show the current color of each pixel
+  strip clear | clear               Clear the strip
+  apply FILE [--dry-run]            Converge the server to a desired state file
+
+Flags:
+`
+
+// errUsage is returned for invalid command lines, after the usage has been printed
+var errUsage = errors.New("usage")
+
+type app struct {
+	client als.Client
+	output string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line in args, returning the exit code
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("alsctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	server := fs.String("server", os.Getenv("ALS_SERVER"), "server host, host:port or base URL (default $ALS_SERVER)")
+	output := fs.String("output", "table", "output format: table or json")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout for the command")
+	validate := fs.Bool("validate", false, "validate animations and sections before sending them")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() == 0 || fs.Arg(0) == "help" {
+		fs.Usage()
+		if fs.NArg() == 0 {
+			return 2
+		}
+		return 0
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "alsctl: unknown output format %q\n", *output)
+		return 2
+	}
+	if *server == "" {
+		fmt.Fprintln(stderr, "alsctl: no server given; use --server or set ALS_SERVER")
+		return 2
+	}
+
+	var opts []als.ClientOption
+	if *validate {
+		opts = append(opts, als.WithValidation())
+	}
+	client, err := newClient(*server, opts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "alsctl: %v\n", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	a := &app{client: client, output: *output, stdout: stdout, stderr: stderr}
+	err = a.dispatch(ctx, fs.Arg(0), fs.Args()[1:])
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		fmt.Fprintf(stderr, "alsctl: %v\n", err)
+		return 1
+	}
+}
+
+// newClient creates a client for a server given as a host, host:port or base URL
+func newClient(server string, opts ...als.ClientOption) (als.Client, error) {
+	if strings.Contains(server, "://") {
+		return als.ALSHttpClient("", append(opts, als.WithBaseURL(server))...), nil
+	}
+	host, portStr, err := net.SplitHostPort(server)
+	if err != nil {
+		return als.ALSHttpClient(server, opts...), nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port in server %q", server)
+	}
+	return als.ALSHttpClient(host, append(opts, als.WithPort(port))...), nil
+}
+
+func (a *app) dispatch(ctx context.Context, cmd string, args []string) error {
+	sub := func() (string, []string) {
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			return "list", args
+		}
+		return args[0], args[1:]
+	}
+
+	switch cmd {
+	case "animations":
+		switch name, rest := sub(); name {
+		case "list":
+			return a.listAnimations(ctx, rest)
+		case "describe":
+			return a.describeAnimation(ctx, rest)
+		}
+	case "start":
+		return a.start(ctx, args)
+	case "running":
+		switch name, rest := sub(); name {
+		case "list":
+			return a.listRunning(ctx, rest)
+		case "describe":
+			return a.describeRunning(ctx, rest)
+		}
+	case "end":
+		return a.end(ctx, args)
+	case "sections":
+		switch name, rest := sub(); name {
+		case "list":
+			return a.listSections(ctx, rest)
+		case "describe":
+			return a.describeSection(ctx, rest)
+		case "create":
+			return a.createSection(ctx, rest)
+		}
+	case "strip":
+		if len(args) == 0 {
+			return a.usageError("strip needs a subcommand: info, color or clear")
+		}
+		switch args[0] {
+		case "info":
+			return a.stripInfo(ctx, args[1:])
+		case "color":
+			return a.stripColor(ctx, args[1:])
+		case "clear":
+			return a.clear(ctx, args[1:])
+		}
+	case "clear":
+		return a.clear(ctx, args)
+	case "apply":
+		return a.apply(ctx, args)
+	default:
+		return a.usageError(fmt.Sprintf("unknown command %q", cmd))
+	}
+	return a.usageError(fmt.Sprintf("unknown %s subcommand %q", cmd, args[0]))
+}
+
+func (a *app) usageError(msg string) error {
+	fmt.Fprintf(a.stderr, "alsctl: %s\nRun alsctl help for usage.\n", msg)
+	return errUsage
+}
+
+// Positional argument counts for parseFlags besides exact counts
+const (
+	atLeastOne = -1
+	anyNumber  = -2
+)
+
+// parseFlags parses a subcommand's flags, which may come before or after its
+// positional arguments, and checks the number of positional arguments
+func (a *app) parseFlags(fs *flag.FlagSet, args []string, nargs int, argsUsage string) ([]string, error) {
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: alsctl %s %s\n", fs.Name(), argsUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(interleave(fs, args)); err != nil {
+		return nil, errUsage
+	}
+	n := fs.NArg()
+	if (nargs >= 0 && n != nargs) || (nargs == atLeastOne && n == 0) {
+		fs.Usage()
+		return nil, errUsage
+	}
+	return fs.Args(), nil
+}
+
+// interleave moves positional arguments after the flags, so flags can be
+// given after a command's arguments (e.g. alsctl start Meteor --color red)
+func interleave(fs *flag.FlagSet, args []string) []string {
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			positional = append(positional, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			flags = append(flags, arg)
+			// Flags without = take the next argument, except booleans
+			if !strings.Contains(arg, "=") && !isBoolFlag(fs, arg) && i+1 < len(args) {
+				i++
+				flags = append(flags, args[i])
+			}
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	return append(append(flags, "--"), positional...)
+}
+
+func isBoolFlag(fs *flag.FlagSet, arg string) bool {
+	f := fs.Lookup(strings.TrimLeft(arg, "-"))
+	if f == nil {
+		// Unknown flags, including -h, are reported by Parse
+		return true
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
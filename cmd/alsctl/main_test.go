@@ -0,0 +1,244 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/alstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer() *alstest.Server {
+	return alstest.NewServer(alstest.WithNumLEDs(10), alstest.WithAnimations(
+		&als.AnimationInfo{Name: "Color", Abbr: "COL", MinimumColors: 1, Description: "Solid color"},
+		&als.AnimationInfo{
+			Name:           "Meteor",
+			Abbr:           "MET",
+			MinimumColors:  1,
+			IntParams:      []*als.AnimationParameter{{Name: "spacing", Default: 3}},
+			LocationParams: []*als.AnimationParameter{{Name: "center", Description: "Where it starts"}},
+		},
+	))
+}
+
+func runCommand(t *testing.T, server *alstest.Server, args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), append([]string{"--server", server.URL}, args...), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestRun_Animations(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	out, _, code := runCommand(t, server, "animations")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "NAME    ABBR  COLORS  DIMENSIONS  DESCRIPTION\n"+
+		"Color   COL   1                   Solid color\n"+
+		"Meteor  MET   1                   \n", out)
+
+	out, _, code = runCommand(t, server, "animations", "describe", "Meteor")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "Name:               Meteor\n")
+	assert.Contains(t, out, "int       spacing    3        \n")
+	assert.Contains(t, out, "location  center     -        Where it starts\n")
+
+	out, _, code = runCommand(t, server, "--output", "json", "animations", "list")
+	assert.Equal(t, 0, code)
+	var animations []*als.AnimationInfo
+	assert.Nil(t, json.Unmarshal([]byte(out), &animations))
+	assert.Len(t, animations, 2)
+}
+
+func TestRun_StartAndEnd(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	out, stderr, code := runCommand(t, server, "--output", "json", "start", "Meteor",
+		"--color", "red,rgb(0, 255, 0),black", "--color", "#0000FF", "--id", "m1", "--run-count", "-1",
+		"--int", "spacing=5", "--double", "speed=1.5", "--string", "text=a=b",
+		"--location", "center=1,2,3", "--distance", "distance=50,0,0,percent",
+		"--rotation", "rotation=0,1.5,0,radians,rotate_y", "--equation", "eq=0,1")
+	assert.Equal(t, 0, code, stderr)
+	var running als.RunningAnimationParams
+	assert.Nil(t, json.Unmarshal([]byte(out), &running))
+	assert.Equal(t, "m1", running.Id)
+	assert.Equal(t, -1, running.RunCount)
+	assert.Equal(t, []int{0xFF0000, 0x00FF00, 0x000000}, running.Colors[0].OriginalColors)
+	assert.Equal(t, []int{0x0000FF}, running.Colors[1].OriginalColors)
+	assert.Equal(t, 5, running.IntParams["spacing"])
+	assert.Equal(t, 1.5, running.DoubleParams["speed"])
+	assert.Equal(t, "a=b", running.StringParams["text"])
+	assert.Equal(t, als.NewLocation(1, 2, 3), running.LocationParams["center"])
+	assert.Equal(t, als.PercentDistance(50, 0, 0), running.DistanceParams["distance"])
+	assert.Equal(t, als.RadiansRotation(0, 1.5, 0, []string{"ROTATE_Y"}), running.RotationParams["rotation"])
+	assert.Equal(t, []float64{0, 1}, running.EquationParams["eq"].Coefficients)
+
+	out, _, code = runCommand(t, server, "running")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "ID  ANIMATION  SECTION    RUN COUNT  COLORS\n"+
+		"m1  Meteor     fullStrip  -1         red,lime,black blue\n", out)
+
+	out, _, code = runCommand(t, server, "running", "describe", "m1")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "rotation  rotation   0,1.5,0,radians,ROTATE_Y\n")
+
+	out, _, code = runCommand(t, server, "end", "--all")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "ENDED  ANIMATION  SECTION\nm1     Meteor     fullStrip\n", out)
+
+	_, stderr, code = runCommand(t, server, "end", "m1")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "ending m1")
+}
+
+func TestRun_Sections(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	out, stderr, code := runCommand(t, server, "sections", "create", "left", "--pixels", "0-4,7")
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, "Name:    left\nParent:  fullStrip\nLEDs:    6\nPixels:  0-4,7\n", out)
+
+	out, _, code = runCommand(t, server, "sections")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "NAME       PARENT     LEDS  PIXELS\n"+
+		"fullStrip             10    0-9\n"+
+		"left       fullStrip  6     0-4,7\n", out)
+
+	out, _, code = runCommand(t, server, "sections", "describe", "left")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "Pixels:  0-4,7\n")
+
+	_, _, code = runCommand(t, server, "sections", "create", "right")
+	assert.Equal(t, 2, code)
+}
+
+func TestRun_Strip(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	server.State.SetStripColor([]int{0xFF0000, 0, 0, 0, 0, 0, 0, 0, 0, 0x0000FF})
+
+	out, _, code := runCommand(t, server, "strip", "info")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "LEDs:                 10\n")
+
+	out, _, code = runCommand(t, server, "--output", "json", "strip", "color")
+	assert.Equal(t, 0, code)
+	var colors []int
+	assert.Nil(t, json.Unmarshal([]byte(out), &colors))
+	assert.Equal(t, 0xFF0000, colors[0])
+
+	out, _, code = runCommand(t, server, "strip", "color")
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(out, "PIXEL  COLOR\n0      #FF0000\n"))
+
+	out, _, code = runCommand(t, server, "strip", "color", "--ansi", "--sections")
+	assert.Equal(t, 0, code)
+	assert.True(t, strings.HasPrefix(out, "fullStrip \x1b[48;2;255;0;0m "))
+
+	out, _, code = runCommand(t, server, "clear")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "Strip cleared\n", out)
+	colors, _ = server.State.GetCurrentStripColor()
+	assert.Equal(t, make([]int, 10), colors)
+}
+
+func TestRun_Apply(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "alsctl")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "state.json")
+	assert.Nil(t, ioutil.WriteFile(file, []byte(`{"animations":[{"animation":"Color","id":"c1"}]}`), 0644))
+
+	out, _, code := runCommand(t, server, "apply", file, "--dry-run")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "+ animation Color on fullStrip (id c1)\n", out)
+	ids, _ := server.State.GetRunningAnimationsIds()
+	assert.Len(t, ids, 0)
+
+	_, _, code = runCommand(t, server, "apply", file)
+	assert.Equal(t, 0, code)
+	ids, _ = server.State.GetRunningAnimationsIds()
+	assert.Equal(t, []string{"c1"}, ids)
+}
+
+func TestRun_Usage(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run(context.Background(), nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "Usage: alsctl")
+
+	os.Setenv("ALS_SERVER", "")
+	assert.Equal(t, 2, run(context.Background(), []string{"strip", "info"}, &stdout, &stderr))
+
+	_, stderr2, code := runCommand(t, server, "bogus")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr2, `unknown command "bogus"`)
+
+	_, _, code = runCommand(t, server, "--output", "yaml", "strip", "info")
+	assert.Equal(t, 2, code)
+
+	_, stderr2, code = runCommand(t, server, "start", "Meteor", "--int", "spacing=x")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr2, "not an integer")
+
+	_, stderr2, code = runCommand(t, server, "start", "Meteor", "--color", "blurple")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr2, "invalid color")
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := newClient("10.0.0.5:9000")
+	assert.Nil(t, err)
+	_, err = newClient("10.0.0.5")
+	assert.Nil(t, err)
+	_, err = newClient("http://als.local:8080/api")
+	assert.Nil(t, err)
+	_, err = newClient("10.0.0.5:port")
+	assert.NotNil(t, err)
+}
+
+func TestParsePixels(t *testing.T) {
+	pixels, err := parsePixels("0-2,5,9-7")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 5, 9, 8, 7}, pixels)
+	assert.Equal(t, "0-2,5,9,8,7", formatPixels(pixels))
+
+	_, err = parsePixels("a-b")
+	assert.NotNil(t, err)
+}
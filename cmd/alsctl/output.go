@@ -0,0 +1,175 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+)
+
+// printJSON writes v as indented JSON
+func (a *app) printJSON(v interface{}) error {
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printTable writes rows as aligned columns under the header
+func (a *app) printTable(header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// printFields writes key/value pairs as two aligned columns
+func (a *app) printFields(fields [][]string) error {
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	for _, f := range fields {
+		fmt.Fprintf(w, "%s:\t%s\n", f[0], f[1])
+	}
+	return w.Flush()
+}
+
+// formatPixels compacts a pixel list into ranges, e.g. "0-9,20"
+func formatPixels(pixels []int) string {
+	var parts []string
+	for i := 0; i < len(pixels); {
+		j := i
+		for j+1 < len(pixels) && pixels[j+1] == pixels[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", pixels[i], pixels[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(pixels[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatFloats(v ...float64) string {
+	strs := make([]string, len(v))
+	for i, f := range v {
+		strs[i] = strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return strings.Join(strs, ",")
+}
+
+// formatValue formats a parameter value in the same syntax the start flags accept
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case *als.Location:
+		return formatFloats(v.X, v.Y, v.Z)
+	case *als.Distance:
+		s := formatFloats(v.X, v.Y, v.Z)
+		if v.DistanceType == "PercentDistance" {
+			s += ",percent"
+		}
+		return s
+	case *als.Rotation:
+		s := formatFloats(v.XRotation, v.YRotation, v.ZRotation)
+		if v.RotationType == "RadiansRotation" {
+			s += ",radians"
+		}
+		if len(v.RotationOrder) > 0 {
+			s += "," + strings.Join(v.RotationOrder, ",")
+		}
+		return s
+	case *als.Equation:
+		return formatFloats(v.Coefficients...)
+	case float64:
+		return formatFloats(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// formatColors formats each container's colors, separating containers with spaces
+func formatColors(containers []*als.ColorContainer) string {
+	strs := make([]string, len(containers))
+	for i, c := range containers {
+		strs[i] = strings.Join(c.Strings(), ",")
+	}
+	return strings.Join(strs, " ")
+}
+
+func formatPreparedColors(containers []*als.PreparedColorContainer) string {
+	originals := make([]*als.ColorContainer, len(containers))
+	for i, c := range containers {
+		originals[i] = als.NewColorContainer(c.OriginalColors)
+	}
+	return formatColors(originals)
+}
+
+// paramRows lists every parameter in the maps of running or requested params
+func paramRows(p *als.RunningAnimationParams) [][]string {
+	var rows [][]string
+	add := func(kind string, name string, value interface{}) {
+		rows = append(rows, []string{kind, name, formatValue(value)})
+	}
+	for _, k := range sortedKeys(p.IntParams) {
+		add("int", k, p.IntParams[k])
+	}
+	for _, k := range sortedKeys(p.DoubleParams) {
+		add("double", k, p.DoubleParams[k])
+	}
+	for _, k := range sortedKeys(p.StringParams) {
+		add("string", k, p.StringParams[k])
+	}
+	for _, k := range sortedKeys(p.LocationParams) {
+		add("location", k, p.LocationParams[k])
+	}
+	for _, k := range sortedKeys(p.DistanceParams) {
+		add("distance", k, p.DistanceParams[k])
+	}
+	for _, k := range sortedKeys(p.RotationParams) {
+		add("rotation", k, p.RotationParams[k])
+	}
+	for _, k := range sortedKeys(p.EquationParams) {
+		add("equation", k, p.EquationParams[k])
+	}
+	return rows
+}
+
+// sortedKeys returns the keys of a map with string keys in order
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	strs := make([]string, len(keys))
+	for i, k := range keys {
+		strs[i] = k.String()
+	}
+	sort.Strings(strs)
+	return strs
+}
@@ -0,0 +1,179 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+)
+
+// multiFlag collects every value of a repeated flag
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, " ")
+}
+
+func (m *multiFlag) Set(value string) error {
+	*m = append(*m, value)
+	return nil
+}
+
+// splitTopLevel splits s on commas that are not inside parentheses, so
+// "red,rgb(0, 0, 255)" is two colors
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// parseColorContainer parses a comma-separated list of colors into a container
+func parseColorContainer(value string) (*als.ColorContainer, error) {
+	colors, err := als.ParseColors(splitTopLevel(value)...)
+	if err != nil {
+		return nil, err
+	}
+	return als.NewColorContainer(colors), nil
+}
+
+// splitParam splits a name=value parameter
+func splitParam(flagName string, param string) (string, string, error) {
+	i := strings.Index(param, "=")
+	if i <= 0 {
+		return "", "", fmt.Errorf("--%s %q must be NAME=VALUE", flagName, param)
+	}
+	return param[:i], param[i+1:], nil
+}
+
+// parseFloats parses exactly n comma-separated numbers, returning any
+// remaining fields
+func parseFloats(value string, n int) ([]float64, []string, error) {
+	fields := strings.Split(value, ",")
+	if len(fields) < n {
+		return nil, nil, fmt.Errorf("%q needs %d comma-separated numbers", value, n)
+	}
+	nums := make([]float64, n)
+	for i := 0; i < n; i++ {
+		f, err := strconv.ParseFloat(strings.TrimSpace(fields[i]), 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%q: %q is not a number", value, fields[i])
+		}
+		nums[i] = f
+	}
+	return nums, fields[n:], nil
+}
+
+func parseLocation(value string) (*als.Location, error) {
+	v, rest, err := parseFloats(value, 3)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%q has extra fields", value)
+	}
+	return als.NewLocation(v[0], v[1], v[2]), nil
+}
+
+// parseDistance parses x,y,z with an optional ",percent" suffix
+func parseDistance(value string) (*als.Distance, error) {
+	v, rest, err := parseFloats(value, 3)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(rest) == 0:
+		return als.AbsoluteDistance(v[0], v[1], v[2]), nil
+	case len(rest) == 1 && rest[0] == "percent":
+		return als.PercentDistance(v[0], v[1], v[2]), nil
+	}
+	return nil, fmt.Errorf("%q: expected x,y,z or x,y,z,percent", value)
+}
+
+// parseRotation parses x,y,z in degrees, with an optional ",radians" suffix
+// followed by an optional rotation order
+func parseRotation(value string) (*als.Rotation, error) {
+	v, rest, err := parseFloats(value, 3)
+	if err != nil {
+		return nil, err
+	}
+	radians := len(rest) > 0 && rest[0] == "radians"
+	if radians {
+		rest = rest[1:]
+	}
+	var order []string
+	for _, r := range rest {
+		order = append(order, strings.ToUpper(strings.TrimSpace(r)))
+	}
+	if radians {
+		return als.RadiansRotation(v[0], v[1], v[2], order), nil
+	}
+	return als.DegreesRotation(v[0], v[1], v[2], order), nil
+}
+
+func parseEquation(value string) (*als.Equation, error) {
+	fields := strings.Split(value, ",")
+	v, _, err := parseFloats(value, len(fields))
+	if err != nil {
+		return nil, err
+	}
+	return als.NewEquation(v), nil
+}
+
+// parsePixels parses a list of pixels and inclusive ranges such as "0-9,20,29-25"
+func parsePixels(value string) ([]int, error) {
+	var pixels []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		bounds := strings.SplitN(part, "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pixel %q", part)
+		}
+		if len(bounds) == 1 {
+			pixels = append(pixels, start)
+			continue
+		}
+		end, err := strconv.Atoi(bounds[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pixel range %q", part)
+		}
+		pixels = append(pixels, als.PixelRange(start, end)...)
+	}
+	return pixels, nil
+}
@@ -0,0 +1,247 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+)
+
+// Just enough of the DNS message format (RFC 1035) for DNS-SD over mDNS
+
+const (
+	typeA    uint16 = 1
+	typePTR  uint16 = 12
+	typeAAAA uint16 = 28
+	typeSRV  uint16 = 33
+
+	classIN uint16 = 1
+	// unicastResponse is set in a question's class to ask for a unicast reply
+	unicastResponse uint16 = 0x8000
+	// cacheFlush is set in a record's class by mDNS responders
+	cacheFlush uint16 = 0x8000
+
+	flagResponse uint16 = 0x8400
+)
+
+var errMalformed = errors.New("malformed DNS message")
+
+type dnsQuestion struct {
+	name  string
+	qtype uint16
+	class uint16
+}
+
+type dnsRecord struct {
+	name   string
+	rtype  uint16
+	class  uint16
+	ttl    uint32
+	ptr    string
+	target string
+	port   uint16
+	ip     net.IP
+}
+
+type dnsMessage struct {
+	id        uint16
+	response  bool
+	questions []dnsQuestion
+	records   []dnsRecord
+}
+
+// fqdn returns name with a single trailing dot
+func fqdn(name string) string {
+	return strings.TrimSuffix(name, ".") + "."
+}
+
+// canonicalName returns name in the form used to compare names, which are case-insensitive
+func canonicalName(name string) string {
+	return strings.ToLower(fqdn(name))
+}
+
+func appendName(b []byte, name string) ([]byte, error) {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		} else if len(label) > 63 {
+			return nil, errors.New("DNS label too long: " + label)
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0), nil
+}
+
+func (m *dnsMessage) pack() ([]byte, error) {
+	b := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(b[0:], m.id)
+	if m.response {
+		binary.BigEndian.PutUint16(b[2:], flagResponse)
+	}
+	binary.BigEndian.PutUint16(b[4:], uint16(len(m.questions)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(m.records)))
+	var err error
+	for _, q := range m.questions {
+		b, err = appendName(b, q.name)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, 0, 0, 0, 0)
+		binary.BigEndian.PutUint16(b[len(b)-4:], q.qtype)
+		binary.BigEndian.PutUint16(b[len(b)-2:], q.class)
+	}
+	for _, r := range m.records {
+		b, err = appendName(b, r.name)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint16(b[len(b)-10:], r.rtype)
+		binary.BigEndian.PutUint16(b[len(b)-8:], r.class)
+		binary.BigEndian.PutUint32(b[len(b)-6:], r.ttl)
+		start := len(b)
+		switch r.rtype {
+		case typePTR:
+			b, err = appendName(b, r.ptr)
+		case typeSRV:
+			b = append(b, 0, 0, 0, 0, 0, 0)
+			binary.BigEndian.PutUint16(b[len(b)-2:], r.port)
+			b, err = appendName(b, r.target)
+		case typeA:
+			b = append(b, r.ip.To4()...)
+		case typeAAAA:
+			b = append(b, r.ip.To16()...)
+		}
+		if err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint16(b[start-2:], uint16(len(b)-start))
+	}
+	return b, nil
+}
+
+// readName reads a possibly compressed name at off, returning the name and the offset after it
+func readName(b []byte, off int) (string, int, error) {
+	var labels []string
+	end := -1
+	for jumps := 0; ; {
+		if off >= len(b) {
+			return "", 0, errMalformed
+		}
+		length := int(b[off])
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return strings.Join(labels, ".") + ".", end, nil
+		case length&0xC0 == 0xC0:
+			if off+1 >= len(b) || jumps > 10 {
+				return "", 0, errMalformed
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(b[off:]) & 0x3FFF)
+			jumps++
+		default:
+			if off+1+length > len(b) {
+				return "", 0, errMalformed
+			}
+			labels = append(labels, string(b[off+1:off+1+length]))
+			off += 1 + length
+		}
+	}
+}
+
+func unpackMessage(b []byte) (*dnsMessage, error) {
+	if len(b) < 12 {
+		return nil, errMalformed
+	}
+	m := &dnsMessage{
+		id:       binary.BigEndian.Uint16(b[0:]),
+		response: binary.BigEndian.Uint16(b[2:])&0x8000 != 0,
+	}
+	qdcount := int(binary.BigEndian.Uint16(b[4:]))
+	rrcount := int(binary.BigEndian.Uint16(b[6:])) + int(binary.BigEndian.Uint16(b[8:])) +
+		int(binary.BigEndian.Uint16(b[10:]))
+	off := 12
+	for i := 0; i < qdcount; i++ {
+		name, next, err := readName(b, off)
+		if err != nil || next+4 > len(b) {
+			return nil, errMalformed
+		}
+		m.questions = append(m.questions, dnsQuestion{
+			name:  name,
+			qtype: binary.BigEndian.Uint16(b[next:]),
+			class: binary.BigEndian.Uint16(b[next+2:]),
+		})
+		off = next + 4
+	}
+	for i := 0; i < rrcount; i++ {
+		name, next, err := readName(b, off)
+		if err != nil || next+10 > len(b) {
+			return nil, errMalformed
+		}
+		r := dnsRecord{
+			name:  name,
+			rtype: binary.BigEndian.Uint16(b[next:]),
+			class: binary.BigEndian.Uint16(b[next+2:]),
+			ttl:   binary.BigEndian.Uint32(b[next+4:]),
+		}
+		length := int(binary.BigEndian.Uint16(b[next+8:]))
+		data := next + 10
+		if data+length > len(b) {
+			return nil, errMalformed
+		}
+		switch r.rtype {
+		case typePTR:
+			r.ptr, _, err = readName(b, data)
+		case typeSRV:
+			if length < 7 {
+				return nil, errMalformed
+			}
+			r.port = binary.BigEndian.Uint16(b[data+4:])
+			r.target, _, err = readName(b, data+6)
+		case typeA:
+			if length != net.IPv4len {
+				return nil, errMalformed
+			}
+			r.ip = net.IP(append([]byte(nil), b[data:data+length]...))
+		case typeAAAA:
+			if length != net.IPv6len {
+				return nil, errMalformed
+			}
+			r.ip = net.IP(append([]byte(nil), b[data:data+length]...))
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.records = append(m.records, r)
+		off = data + length
+	}
+	return m, nil
+}
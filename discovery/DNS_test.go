@@ -0,0 +1,80 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package discovery
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDNSMessage_RoundTrip(t *testing.T) {
+	msg := &dnsMessage{
+		id:        7,
+		response:  true,
+		questions: []dnsQuestion{{name: "_animatedledstrip._tcp.local.", qtype: typePTR, class: classIN}},
+		records: []dnsRecord{
+			{name: "_animatedledstrip._tcp.local.", rtype: typePTR, class: classIN, ttl: 120, ptr: "Lobby._animatedledstrip._tcp.local."},
+			{name: "Lobby._animatedledstrip._tcp.local.", rtype: typeSRV, class: classIN, ttl: 120, target: "lobby.local.", port: 8080},
+			{name: "lobby.local.", rtype: typeA, class: classIN, ttl: 120, ip: net.ParseIP("10.0.0.254").To4()},
+			{name: "lobby.local.", rtype: typeAAAA, class: classIN, ttl: 120, ip: net.ParseIP("fe80::1")},
+		},
+	}
+	b, err := msg.pack()
+	assert.Nil(t, err)
+
+	unpacked, err := unpackMessage(b)
+	assert.Nil(t, err)
+	assert.Equal(t, msg, unpacked)
+}
+
+func TestDNSMessage_Compression(t *testing.T) {
+	b := []byte{
+		0, 0, 0x84, 0, 0, 0, 0, 2, 0, 0, 0, 0,
+		// _als._tcp.local PTR -> Lobby.<pointer to offset 12>
+		4, '_', 'a', 'l', 's', 4, '_', 't', 'c', 'p', 5, 'l', 'o', 'c', 'a', 'l', 0,
+		0, 12, 0, 1, 0, 0, 0, 120, 0, 8,
+		5, 'L', 'o', 'b', 'b', 'y', 0xC0, 12,
+		// <pointer to offset 22> (local) A 10.0.0.1
+		0xC0, 22, 0, 1, 0, 1, 0, 0, 0, 120, 0, 4, 10, 0, 0, 1,
+	}
+	msg, err := unpackMessage(b)
+	assert.Nil(t, err)
+	assert.True(t, msg.response)
+	assert.Equal(t, "_als._tcp.local.", msg.records[0].name)
+	assert.Equal(t, "Lobby._als._tcp.local.", msg.records[0].ptr)
+	assert.Equal(t, "local.", msg.records[1].name)
+	assert.Equal(t, "10.0.0.1", msg.records[1].ip.String())
+}
+
+func TestDNSMessage_Malformed(t *testing.T) {
+	_, err := unpackMessage([]byte{0, 0, 0x84})
+	assert.NotNil(t, err)
+
+	_, err = unpackMessage([]byte{0, 0, 0x84, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0xC0, 12})
+	assert.NotNil(t, err)
+
+	_, err = unpackMessage([]byte{0, 0, 0x84, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 9, 1})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,349 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+// Package discovery finds AnimatedLEDStrip servers on the local network
+// with mDNS/DNS-SD browsing and by probing a subnet
+package discovery
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+)
+
+const (
+	// ServiceType is the DNS-SD service type AnimatedLEDStrip servers advertise
+	ServiceType = "_animatedledstrip._tcp"
+	// MulticastAddress is the mDNS IPv4 multicast address
+	MulticastAddress = "224.0.0.251:5353"
+)
+
+// Server is a discovered AnimatedLEDStrip server
+type Server struct {
+	// Name is the DNS-SD instance name, or the address for probed servers
+	Name      string
+	Address   string
+	Port      int
+	StripInfo *als.StripInfo
+}
+
+// Key uniquely identifies the server by its address and port
+func (s *Server) Key() string {
+	return net.JoinHostPort(s.Address, strconv.Itoa(s.Port))
+}
+
+// Client returns an HTTP client for the server
+func (s *Server) Client(opts ...als.ClientOption) als.Client {
+	return als.ALSHttpClient(s.Address, append([]als.ClientOption{als.WithPort(s.Port)}, opts...)...)
+}
+
+// BrowseOptions configures Browse; a nil *BrowseOptions uses the defaults
+type BrowseOptions struct {
+	// Service is the DNS-SD service type to browse for (default ServiceType)
+	Service string
+	// Domain is the DNS-SD domain (default "local")
+	Domain string
+	// Address the query is sent to (default MulticastAddress).
+	// It can be set to a unicast address, such as a Responder on loopback.
+	Address string
+	// Timeout is how long to wait for responses (default 2s)
+	Timeout time.Duration
+	// ClientOptions are used when fetching each server's strip info
+	ClientOptions []als.ClientOption
+}
+
+func (o *BrowseOptions) withDefaults() BrowseOptions {
+	var opts BrowseOptions
+	if o != nil {
+		opts = *o
+	}
+	if opts.Service == "" {
+		opts.Service = ServiceType
+	}
+	if opts.Domain == "" {
+		opts.Domain = "local"
+	}
+	if opts.Address == "" {
+		opts.Address = MulticastAddress
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	return opts
+}
+
+func serviceName(service string, domain string) string {
+	return canonicalName(service + "." + domain)
+}
+
+// Browse sends a DNS-SD query for AnimatedLEDStrip servers and collects responses until
+// the timeout or ctx is done.
+// Only servers whose strip info could be fetched are returned.
+func Browse(ctx context.Context, opts *BrowseOptions) ([]*Server, error) {
+	o := opts.withDefaults()
+	addr, err := net.ResolveUDPAddr("udp", o.Address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	service := serviceName(o.Service, o.Domain)
+	query, err := (&dnsMessage{
+		questions: []dnsQuestion{{name: service, qtype: typePTR, class: classIN | unicastResponse}},
+	}).pack()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = conn.WriteToUDP(query, addr); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(o.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetReadDeadline(deadline)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetReadDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	var records []dnsRecord
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break
+		}
+		msg, err := unpackMessage(buf[:n])
+		if err != nil || !msg.response {
+			continue
+		}
+		records = append(records, msg.records...)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return withStripInfo(ctx, resolveServers(service, records), o.ClientOptions), nil
+}
+
+// resolveServers follows PTR, SRV and A/AAAA records to find servers of service
+func resolveServers(service string, records []dnsRecord) []*Server {
+	srvs := map[string]dnsRecord{}
+	ips := map[string]net.IP{}
+	var instances []string
+	for _, r := range records {
+		switch r.rtype {
+		case typePTR:
+			if canonicalName(r.name) == service {
+				instances = append(instances, fqdn(r.ptr))
+			}
+		case typeSRV:
+			srvs[canonicalName(r.name)] = r
+		case typeA:
+			ips[canonicalName(r.name)] = r.ip
+		case typeAAAA:
+			if _, ok := ips[canonicalName(r.name)]; !ok {
+				ips[canonicalName(r.name)] = r.ip
+			}
+		}
+	}
+	seen := map[string]bool{}
+	var servers []*Server
+	for _, instance := range instances {
+		key := canonicalName(instance)
+		srv, ok := srvs[key]
+		if !ok || seen[key] {
+			continue
+		}
+		ip, ok := ips[canonicalName(srv.target)]
+		if !ok {
+			continue
+		}
+		seen[key] = true
+		name := strings.TrimSuffix(instance, ".")
+		if strings.HasSuffix(key, "."+service) {
+			name = instance[:len(instance)-len(service)-1]
+		}
+		servers = append(servers, &Server{
+			Name:    name,
+			Address: ip.String(),
+			Port:    int(srv.port),
+		})
+	}
+	return servers
+}
+
+// withStripInfo fetches the strip info of every server concurrently,
+// dropping the servers that don't respond
+func withStripInfo(ctx context.Context, servers []*Server, clientOpts []als.ClientOption) []*Server {
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server *Server) {
+			defer wg.Done()
+			info, err := server.Client(clientOpts...).GetStripInfoContext(ctx)
+			if err == nil {
+				server.StripInfo = info
+			}
+		}(server)
+	}
+	wg.Wait()
+	found := make([]*Server, 0, len(servers))
+	for _, server := range servers {
+		if server.StripInfo != nil {
+			found = append(found, server)
+		}
+	}
+	sortServers(found)
+	return found
+}
+
+func sortServers(servers []*Server) {
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].Key() < servers[j].Key()
+	})
+}
+
+// ProbeOptions configures ProbeSubnet; a nil *ProbeOptions uses the defaults
+type ProbeOptions struct {
+	// Port the servers listen on (default 8080)
+	Port int
+	// Timeout for each probe (default 500ms)
+	Timeout time.Duration
+	// Concurrency is the maximum number of probes in flight (default 64)
+	Concurrency int
+	// ClientOptions are used for each probe
+	ClientOptions []als.ClientOption
+}
+
+// maxProbeHosts limits the size of the subnet ProbeSubnet will scan
+const maxProbeHosts = 1 << 16
+
+// ProbeSubnet requests /strip/info from every host in cidr (e.g. "10.0.0.0/24")
+// and returns the hosts that respond
+func ProbeSubnet(ctx context.Context, cidr string, opts *ProbeOptions) ([]*Server, error) {
+	var o ProbeOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Port == 0 {
+		o.Port = 8080
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 500 * time.Millisecond
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 64
+	}
+	hosts, err := subnetHosts(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := &http.Client{Timeout: o.Timeout}
+	clientOpts := append([]als.ClientOption{als.WithHTTPClient(httpClient)}, o.ClientOptions...)
+	sem := make(chan struct{}, o.Concurrency)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var found []*Server
+	for _, host := range hosts {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			server := &Server{Name: host, Address: host, Port: o.Port}
+			info, err := server.Client(clientOpts...).GetStripInfoContext(ctx)
+			if err != nil {
+				return
+			}
+			server.StripInfo = info
+			mu.Lock()
+			found = append(found, server)
+			mu.Unlock()
+		}(host)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	sortServers(found)
+	return found, nil
+}
+
+// subnetHosts lists the host addresses in cidr, excluding the network
+// and broadcast addresses of IPv4 subnets larger than /31
+func subnetHosts(cidr string) ([]string, error) {
+	ip, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	ones, bits := ipNet.Mask.Size()
+	if bits-ones > 16 {
+		return nil, &net.ParseError{Type: "subnet of at most " + strconv.Itoa(maxProbeHosts) + " hosts", Text: cidr}
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	start := ip.Mask(ipNet.Mask)
+	count := 1 << uint(bits-ones)
+	hosts := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		if bits == 32 && bits-ones > 1 && (i == 0 || i == count-1) {
+			continue
+		}
+		hosts = append(hosts, addToIP(start, i).String())
+	}
+	return hosts, nil
+}
+
+func addToIP(ip net.IP, n int) net.IP {
+	result := append(net.IP(nil), ip...)
+	for i := len(result) - 1; i >= 0 && n > 0; i-- {
+		sum := int(result[i]) + n
+		result[i] = byte(sum)
+		n = sum >> 8
+	}
+	return result
+}
@@ -0,0 +1,145 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package discovery
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/alstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func serverPort(t *testing.T, server *alstest.Server) int {
+	u, err := url.Parse(server.URL)
+	assert.Nil(t, err)
+	port, err := strconv.Atoi(u.Port())
+	assert.Nil(t, err)
+	return port
+}
+
+func TestBrowse(t *testing.T) {
+	server := alstest.NewServer(alstest.WithNumLEDs(60))
+	defer server.Close()
+
+	responder, err := NewResponder("127.0.0.1:0",
+		Advertisement{Instance: "Lobby", IP: net.ParseIP("127.0.0.1"), Port: serverPort(t, server)})
+	assert.Nil(t, err)
+	defer responder.Close()
+
+	servers, err := Browse(context.Background(), &BrowseOptions{Address: responder.Addr(), Timeout: 200 * time.Millisecond})
+	assert.Nil(t, err)
+	assert.Len(t, servers, 1)
+	assert.Equal(t, "Lobby", servers[0].Name)
+	assert.Equal(t, "127.0.0.1", servers[0].Address)
+	assert.Equal(t, serverPort(t, server), servers[0].Port)
+	assert.Equal(t, 60, servers[0].StripInfo.NumLEDs)
+
+	info, err := servers[0].Client().GetStripInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, 60, info.NumLEDs)
+}
+
+func TestBrowse_IgnoresOtherServices(t *testing.T) {
+	server := alstest.NewServer()
+	defer server.Close()
+
+	responder, err := NewResponder("127.0.0.1:0", Advertisement{
+		Instance: "Lobby",
+		IP:       net.ParseIP("127.0.0.1"),
+		Port:     serverPort(t, server),
+		Service:  "_other._tcp",
+	})
+	assert.Nil(t, err)
+	defer responder.Close()
+
+	servers, err := Browse(context.Background(), &BrowseOptions{Address: responder.Addr(), Timeout: 100 * time.Millisecond})
+	assert.Nil(t, err)
+	assert.Len(t, servers, 0)
+}
+
+func TestBrowse_DropsUnreachableServers(t *testing.T) {
+	server := alstest.NewServer()
+	port := serverPort(t, server)
+	server.Close()
+
+	responder, err := NewResponder("127.0.0.1:0", Advertisement{Instance: "Lobby", IP: net.ParseIP("127.0.0.1"), Port: port})
+	assert.Nil(t, err)
+	defer responder.Close()
+
+	servers, err := Browse(context.Background(), &BrowseOptions{Address: responder.Addr(), Timeout: 100 * time.Millisecond})
+	assert.Nil(t, err)
+	assert.Len(t, servers, 0)
+}
+
+func TestBrowse_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Browse(ctx, &BrowseOptions{Address: "127.0.0.1:9", Timeout: time.Second})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestProbeSubnet(t *testing.T) {
+	server := alstest.NewServer(alstest.WithNumLEDs(30))
+	defer server.Close()
+
+	servers, err := ProbeSubnet(context.Background(), "127.0.0.1/32", &ProbeOptions{Port: serverPort(t, server)})
+	assert.Nil(t, err)
+	assert.Len(t, servers, 1)
+	assert.Equal(t, "127.0.0.1", servers[0].Address)
+	assert.Equal(t, 30, servers[0].StripInfo.NumLEDs)
+
+	_, err = ProbeSubnet(context.Background(), "10.0.0.0/8", nil)
+	assert.NotNil(t, err)
+	_, err = ProbeSubnet(context.Background(), "not a subnet", nil)
+	assert.NotNil(t, err)
+}
+
+func TestSubnetHosts(t *testing.T) {
+	hosts, err := subnetHosts("10.0.0.0/30")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, hosts)
+
+	hosts, _ = subnetHosts("10.0.0.5/31")
+	assert.Equal(t, []string{"10.0.0.4", "10.0.0.5"}, hosts)
+
+	hosts, _ = subnetHosts("10.0.0.255/23")
+	assert.Len(t, hosts, 510)
+	assert.Equal(t, "10.0.0.255", hosts[254])
+	assert.Equal(t, "10.0.1.0", hosts[255])
+
+	hosts, _ = subnetHosts("fe80::/126")
+	assert.Equal(t, []string{"fe80::", "fe80::1", "fe80::2", "fe80::3"}, hosts)
+}
+
+func TestServer_Key(t *testing.T) {
+	assert.Equal(t, "10.0.0.254:8080", (&Server{Address: "10.0.0.254", Port: 8080}).Key())
+	assert.Equal(t, "[fe80::1]:8080", (&Server{Address: "fe80::1", Port: 8080}).Key())
+	var _ als.Client = (&Server{Address: "10.0.0.254", Port: 8080}).Client()
+}
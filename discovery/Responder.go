@@ -0,0 +1,134 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package discovery
+
+import (
+	"net"
+	"strings"
+)
+
+// Advertisement describes the server a Responder advertises
+type Advertisement struct {
+	// Instance is the DNS-SD instance name, e.g. "Lobby"
+	Instance string
+	// Host is the host name (default Instance with spaces replaced by dashes)
+	Host string
+	IP   net.IP
+	Port int
+	// Service is the DNS-SD service type (default ServiceType)
+	Service string
+	// Domain is the DNS-SD domain (default "local")
+	Domain string
+}
+
+// Responder answers DNS-SD queries for a single AnimatedLEDStrip server.
+// It can advertise a server on the mDNS multicast address,
+// or listen on a unicast address (such as loopback) for testing Browse.
+type Responder struct {
+	ad   Advertisement
+	conn *net.UDPConn
+	done chan struct{}
+}
+
+// NewResponder starts a Responder listening on listenAddr.
+// If listenAddr is a multicast address, the multicast group is joined on the default interface.
+func NewResponder(listenAddr string, ad Advertisement) (*Responder, error) {
+	if ad.Host == "" {
+		ad.Host = strings.Replace(ad.Instance, " ", "-", -1)
+	}
+	if ad.Service == "" {
+		ad.Service = ServiceType
+	}
+	if ad.Domain == "" {
+		ad.Domain = "local"
+	}
+	addr, err := net.ResolveUDPAddr("udp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	var conn *net.UDPConn
+	if addr.IP.IsMulticast() {
+		conn, err = net.ListenMulticastUDP("udp", nil, addr)
+	} else {
+		conn, err = net.ListenUDP("udp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := &Responder{ad: ad, conn: conn, done: make(chan struct{})}
+	go r.serve()
+	return r, nil
+}
+
+// Addr returns the address the responder is listening on
+func (r *Responder) Addr() string {
+	return r.conn.LocalAddr().String()
+}
+
+// Close stops the responder
+func (r *Responder) Close() error {
+	err := r.conn.Close()
+	<-r.done
+	return err
+}
+
+func (r *Responder) records() []dnsRecord {
+	service := fqdn(r.ad.Service + "." + r.ad.Domain)
+	instance := fqdn(r.ad.Instance + "." + service)
+	host := fqdn(r.ad.Host + "." + r.ad.Domain)
+	addrType := typeA
+	if r.ad.IP.To4() == nil {
+		addrType = typeAAAA
+	}
+	return []dnsRecord{
+		{name: service, rtype: typePTR, class: classIN, ttl: 120, ptr: instance},
+		{name: instance, rtype: typeSRV, class: classIN | cacheFlush, ttl: 120, target: host, port: uint16(r.ad.Port)},
+		{name: host, rtype: addrType, class: classIN | cacheFlush, ttl: 120, ip: r.ad.IP},
+	}
+}
+
+func (r *Responder) serve() {
+	defer close(r.done)
+	buf := make([]byte, 9000)
+	service := serviceName(r.ad.Service, r.ad.Domain)
+	for {
+		n, from, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		query, err := unpackMessage(buf[:n])
+		if err != nil || query.response {
+			continue
+		}
+		for _, q := range query.questions {
+			if canonicalName(q.name) != service || q.qtype != typePTR {
+				continue
+			}
+			resp, err := (&dnsMessage{id: query.id, response: true, records: r.records()}).pack()
+			if err == nil {
+				_, _ = r.conn.WriteToUDP(resp, from)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package discovery
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DiscoverFunc finds servers, e.g. by calling Browse or ProbeSubnet
+type DiscoverFunc func(ctx context.Context) ([]*Server, error)
+
+// BrowseFunc returns a DiscoverFunc that calls Browse with opts
+func BrowseFunc(opts *BrowseOptions) DiscoverFunc {
+	return func(ctx context.Context) ([]*Server, error) {
+		return Browse(ctx, opts)
+	}
+}
+
+// ProbeFunc returns a DiscoverFunc that calls ProbeSubnet with cidr and opts
+func ProbeFunc(cidr string, opts *ProbeOptions) DiscoverFunc {
+	return func(ctx context.Context) ([]*Server, error) {
+		return ProbeSubnet(ctx, cidr, opts)
+	}
+}
+
+// ChangeType identifies whether a server was added or removed
+type ChangeType string
+
+const (
+	ServerAdded   ChangeType = "ServerAdded"
+	ServerRemoved ChangeType = "ServerRemoved"
+)
+
+// Change is a server appearing or disappearing
+type Change struct {
+	Type   ChangeType
+	Server *Server
+}
+
+// Watcher keeps a live list of servers by running discovery repeatedly
+type Watcher struct {
+	interval time.Duration
+	discover []DiscoverFunc
+	changes  chan *Change
+
+	mu      sync.Mutex
+	servers map[string]*Server
+}
+
+// DefaultWatchInterval is the interval a Watcher uses when NewWatcher is
+// given one that isn't positive
+const DefaultWatchInterval = 30 * time.Second
+
+// NewWatcher creates a Watcher that runs every discover function each interval.
+// An interval that isn't positive is replaced with DefaultWatchInterval.
+func NewWatcher(interval time.Duration, discover ...DiscoverFunc) *Watcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	return &Watcher{
+		interval: interval,
+		discover: discover,
+		changes:  make(chan *Change, 16),
+		servers:  map[string]*Server{},
+	}
+}
+
+// Changes returns the channel changes are sent on; it is closed when Run returns
+func (w *Watcher) Changes() <-chan *Change {
+	return w.changes
+}
+
+// Servers returns the servers found by the latest scan
+func (w *Watcher) Servers() []*Server {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	servers := make([]*Server, 0, len(w.servers))
+	for _, server := range w.servers {
+		servers = append(servers, server)
+	}
+	sortServers(servers)
+	return servers
+}
+
+// Run scans for servers until ctx is done.
+// A server is removed when a scan in which every discover function succeeded doesn't find it.
+func (w *Watcher) Run(ctx context.Context) {
+	defer close(w.changes)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if !w.scan(ctx) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan runs discovery once and sends the changes, returning false if ctx is done
+func (w *Watcher) scan(ctx context.Context) bool {
+	found := map[string]*Server{}
+	complete := true
+	for _, discover := range w.discover {
+		servers, err := discover(ctx)
+		if ctx.Err() != nil {
+			return false
+		} else if err != nil {
+			complete = false
+			continue
+		}
+		for _, server := range servers {
+			found[server.Key()] = server
+		}
+	}
+
+	var changes []*Change
+	w.mu.Lock()
+	for key, server := range found {
+		if _, ok := w.servers[key]; !ok {
+			changes = append(changes, &Change{Type: ServerAdded, Server: server})
+		}
+		w.servers[key] = server
+	}
+	if complete {
+		for key, server := range w.servers {
+			if _, ok := found[key]; !ok {
+				changes = append(changes, &Change{Type: ServerRemoved, Server: server})
+				delete(w.servers, key)
+			}
+		}
+	}
+	w.mu.Unlock()
+
+	for _, change := range changes {
+		select {
+		case w.changes <- change:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
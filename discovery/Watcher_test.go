@@ -0,0 +1,115 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type scripted struct {
+	mu    sync.Mutex
+	scans [][]*Server
+	errs  []error
+}
+
+func (s *scripted) discover(ctx context.Context) ([]*Server, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.scans) == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	servers, err := s.scans[0], s.errs[0]
+	s.scans, s.errs = s.scans[1:], s.errs[1:]
+	return servers, err
+}
+
+func nextChange(t *testing.T, w *Watcher) *Change {
+	select {
+	case change := <-w.Changes():
+		return change
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for change")
+		return nil
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	a := &Server{Name: "a", Address: "10.0.0.1", Port: 8080}
+	b := &Server{Name: "b", Address: "10.0.0.2", Port: 8080}
+	s := &scripted{
+		scans: [][]*Server{{a}, {a, b}, nil, {b}},
+		errs:  []error{nil, nil, errors.New("network unreachable"), nil},
+	}
+	w := NewWatcher(time.Millisecond, s.discover)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	change := nextChange(t, w)
+	assert.Equal(t, ServerAdded, change.Type)
+	assert.Equal(t, "a", change.Server.Name)
+
+	change = nextChange(t, w)
+	assert.Equal(t, ServerAdded, change.Type)
+	assert.Equal(t, "b", change.Server.Name)
+
+	// The failed scan doesn't remove a
+	change = nextChange(t, w)
+	assert.Equal(t, ServerRemoved, change.Type)
+	assert.Equal(t, "a", change.Server.Name)
+
+	assert.Eventually(t, func() bool { return len(w.Servers()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, "b", w.Servers()[0].Name)
+
+	cancel()
+	<-done
+	_, ok := <-w.Changes()
+	assert.False(t, ok)
+}
+
+func TestWatcher_DefaultInterval(t *testing.T) {
+	assert.Equal(t, DefaultWatchInterval, NewWatcher(0).interval)
+	assert.Equal(t, DefaultWatchInterval, NewWatcher(-time.Second).interval)
+
+	// Run doesn't panic with a defaulted interval
+	ctx, cancel := context.WithCancel(context.Background())
+	w := NewWatcher(0, (&scripted{scans: [][]*Server{nil}, errs: []error{nil}}).discover)
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	cancel()
+	<-done
+}
@@ -0,0 +1,471 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+// Package fake provides an in-memory implementation of animatedledstrip.Client
+// for testing applications without an AnimatedLEDStrip server
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+)
+
+// FullStripSectionName is the name of the section covering the whole strip
+const FullStripSectionName = "fullStrip"
+
+// Client is an in-memory animatedledstrip.Client that tracks
+// supported animations, sections, running animations and the strip color
+type Client struct {
+	mu         sync.Mutex
+	stripInfo  als.StripInfo
+	animations map[string]*als.AnimationInfo
+	sections   map[string]*als.Section
+	running    map[string]*als.RunningAnimationParams
+	stripColor []int
+	nextId     int
+	listeners  map[chan *als.Event]struct{}
+}
+
+var _ als.Client = (*Client)(nil)
+var _ als.Subscriber = (*Client)(nil)
+
+const eventBufferSize = 64
+
+// NewClient creates a fake client for a strip with numLEDs pixels,
+// with a fullStrip section and the given supported animations
+func NewClient(numLEDs int, animations ...*als.AnimationInfo) *Client {
+	pixels := make([]int, numLEDs)
+	for i := range pixels {
+		pixels[i] = i
+	}
+	c := &Client{
+		stripInfo: als.StripInfo{
+			NumLEDs:           numLEDs,
+			Pin:               -1,
+			RendersBeforeSave: -1,
+			ThreadCount:       -1,
+		},
+		animations: map[string]*als.AnimationInfo{},
+		sections: map[string]*als.Section{
+			FullStripSectionName: als.NewSection(FullStripSectionName, pixels, ""),
+		},
+		running:    map[string]*als.RunningAnimationParams{},
+		stripColor: make([]int, numLEDs),
+		listeners:  map[chan *als.Event]struct{}{},
+	}
+	for _, info := range animations {
+		c.AddAnimation(info)
+	}
+	return c
+}
+
+// AddAnimation adds an animation to the supported animations
+func (c *Client) AddAnimation(info *als.AnimationInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.animations[info.Name] = info.Copy()
+}
+
+// SetStripColor sets the colors returned by GetCurrentStripColor
+func (c *Client) SetStripColor(colors []int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stripColor = append([]int(nil), colors...)
+}
+
+// Subscribe delivers an Event for every animation started or ended,
+// section created and strip clear until ctx is done, when the channel is closed.
+// opts is ignored. Events are dropped if the channel's buffer of 64 events is full.
+func (c *Client) Subscribe(ctx context.Context, opts *als.SubscribeOptions) (<-chan *als.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	events := make(chan *als.Event, eventBufferSize)
+	c.mu.Lock()
+	c.listeners[events] = struct{}{}
+	c.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.listeners, events)
+		close(events)
+	}()
+	return events, nil
+}
+
+// publish sends event to every subscriber; c.mu must be held
+func (c *Client) publish(event *als.Event) {
+	for listener := range c.listeners {
+		select {
+		case listener <- event:
+		default:
+		}
+	}
+}
+
+func notFound(method string, path string, body string) error {
+	return &als.APIError{Method: method, URL: path, StatusCode: http.StatusNotFound, Body: body}
+}
+
+func badRequest(method string, path string, body string) error {
+	return &als.APIError{Method: method, URL: path, StatusCode: http.StatusBadRequest, Body: body}
+}
+
+func copySection(sect *als.Section) *als.Section {
+	return als.NewSection(sect.Name, append([]int(nil), sect.Pixels...), sect.ParentSectionName)
+}
+
+func (c *Client) GetAnimationInfo(name string) (*als.AnimationInfo, error) {
+	return c.GetAnimationInfoContext(context.Background(), name)
+}
+
+func (c *Client) GetAnimationInfoContext(ctx context.Context, name string) (*als.AnimationInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, ok := c.animations[name]
+	if !ok {
+		return nil, notFound(http.MethodGet, "/animation/"+name, fmt.Sprintf("Animation %s not found", name))
+	}
+	return info.Copy(), nil
+}
+
+func (c *Client) GetSupportedAnimationsNames() ([]string, error) {
+	return c.GetSupportedAnimationsNamesContext(context.Background())
+}
+
+func (c *Client) GetSupportedAnimationsNamesContext(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.animations))
+	for name := range c.animations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (c *Client) GetSupportedAnimations() ([]*als.AnimationInfo, error) {
+	return c.GetSupportedAnimationsContext(context.Background())
+}
+
+func (c *Client) GetSupportedAnimationsContext(ctx context.Context) ([]*als.AnimationInfo, error) {
+	names, err := c.GetSupportedAnimationsNamesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	infos := make([]*als.AnimationInfo, 0, len(names))
+	for _, name := range names {
+		infos = append(infos, c.animations[name].Copy())
+	}
+	return infos, nil
+}
+
+func (c *Client) GetSupportedAnimationsMap() (map[string]*als.AnimationInfo, error) {
+	return c.GetSupportedAnimationsMapContext(context.Background())
+}
+
+func (c *Client) GetSupportedAnimationsMapContext(ctx context.Context) (map[string]*als.AnimationInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	infos := make(map[string]*als.AnimationInfo, len(c.animations))
+	for name, info := range c.animations {
+		infos[name] = info.Copy()
+	}
+	return infos, nil
+}
+
+func (c *Client) GetRunningAnimations() (map[string]*als.RunningAnimationParams, error) {
+	return c.GetRunningAnimationsContext(context.Background())
+}
+
+func (c *Client) GetRunningAnimationsContext(ctx context.Context) (map[string]*als.RunningAnimationParams, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	running := make(map[string]*als.RunningAnimationParams, len(c.running))
+	for id, params := range c.running {
+		running[id] = params.Copy()
+	}
+	return running, nil
+}
+
+func (c *Client) GetRunningAnimationsIds() ([]string, error) {
+	return c.GetRunningAnimationsIdsContext(context.Background())
+}
+
+func (c *Client) GetRunningAnimationsIdsContext(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]string, 0, len(c.running))
+	for id := range c.running {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (c *Client) GetRunningAnimationParams(id string) (*als.RunningAnimationParams, error) {
+	return c.GetRunningAnimationParamsContext(context.Background(), id)
+}
+
+func (c *Client) GetRunningAnimationParamsContext(ctx context.Context, id string) (*als.RunningAnimationParams, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	params, ok := c.running[id]
+	if !ok {
+		return nil, notFound(http.MethodGet, "/running/"+id, fmt.Sprintf("Animation %s not found", id))
+	}
+	return params.Copy(), nil
+}
+
+func (c *Client) EndAnimation(id string) (*als.RunningAnimationParams, error) {
+	return c.EndAnimationContext(context.Background(), id)
+}
+
+func (c *Client) EndAnimationContext(ctx context.Context, id string) (*als.RunningAnimationParams, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	params, ok := c.running[id]
+	if !ok {
+		return nil, notFound(http.MethodDelete, "/running/"+id, fmt.Sprintf("Animation %s not found", id))
+	}
+	delete(c.running, id)
+	c.publish(&als.Event{Type: als.AnimationEnded, Animation: params.Copy()})
+	return params, nil
+}
+
+func (c *Client) EndAnimationFromParams(params *als.RunningAnimationParams) (*als.RunningAnimationParams, error) {
+	return c.EndAnimationFromParamsContext(context.Background(), params)
+}
+
+func (c *Client) EndAnimationFromParamsContext(ctx context.Context, params *als.RunningAnimationParams) (*als.RunningAnimationParams, error) {
+	return c.EndAnimationContext(ctx, params.Id)
+}
+
+func (c *Client) GetSections() ([]*als.Section, error) {
+	return c.GetSectionsContext(context.Background())
+}
+
+func (c *Client) GetSectionsContext(ctx context.Context) ([]*als.Section, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.sections))
+	for name := range c.sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sects := make([]*als.Section, 0, len(names))
+	for _, name := range names {
+		sects = append(sects, copySection(c.sections[name]))
+	}
+	return sects, nil
+}
+
+func (c *Client) GetSectionsMap() (map[string]*als.Section, error) {
+	return c.GetSectionsMapContext(context.Background())
+}
+
+func (c *Client) GetSectionsMapContext(ctx context.Context) (map[string]*als.Section, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sects := make(map[string]*als.Section, len(c.sections))
+	for name, sect := range c.sections {
+		sects[name] = copySection(sect)
+	}
+	return sects, nil
+}
+
+func (c *Client) GetSection(name string) (*als.Section, error) {
+	return c.GetSectionContext(context.Background(), name)
+}
+
+func (c *Client) GetSectionContext(ctx context.Context, name string) (*als.Section, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sect, ok := c.sections[name]
+	if !ok {
+		return nil, notFound(http.MethodGet, "/section/"+name, fmt.Sprintf("Section %s not found", name))
+	}
+	return copySection(sect), nil
+}
+
+func (c *Client) GetFullStripSection() (*als.Section, error) {
+	return c.GetFullStripSectionContext(context.Background())
+}
+
+func (c *Client) GetFullStripSectionContext(ctx context.Context) (*als.Section, error) {
+	return c.GetSectionContext(ctx, FullStripSectionName)
+}
+
+func (c *Client) CreateNewSection(newSection *als.Section) (*als.Section, error) {
+	return c.CreateNewSectionContext(context.Background(), newSection)
+}
+
+func (c *Client) CreateNewSectionContext(ctx context.Context, newSection *als.Section) (*als.Section, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	parentName := newSection.ParentSectionName
+	if parentName == "" {
+		parentName = FullStripSectionName
+	}
+	if _, ok := c.sections[parentName]; !ok {
+		return nil, badRequest(http.MethodPost, "/sections", fmt.Sprintf("Parent section %s not found", parentName))
+	}
+	for _, pixel := range newSection.Pixels {
+		if pixel < 0 || pixel >= c.stripInfo.NumLEDs {
+			return nil, badRequest(http.MethodPost, "/sections", fmt.Sprintf("Pixel %d is out of bounds", pixel))
+		}
+	}
+	sect := copySection(newSection)
+	sect.ParentSectionName = parentName
+	c.sections[sect.Name] = sect
+	c.publish(&als.Event{Type: als.SectionCreated, Section: copySection(sect)})
+	return copySection(sect), nil
+}
+
+func (c *Client) StartAnimation(newAnim *als.AnimationToRunParams) (*als.RunningAnimationParams, error) {
+	return c.StartAnimationContext(context.Background(), newAnim)
+}
+
+func (c *Client) StartAnimationContext(ctx context.Context, newAnim *als.AnimationToRunParams) (*als.RunningAnimationParams, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// The caller may reuse newAnim, so keep a copy of our own
+	newAnim = newAnim.Copy()
+	info, ok := c.animations[newAnim.Animation]
+	if !ok {
+		return nil, badRequest(http.MethodPost, "/start", fmt.Sprintf("Animation %s not found", newAnim.Animation))
+	}
+	sectionName := newAnim.Section
+	if sectionName == "" {
+		sectionName = FullStripSectionName
+	}
+	section, ok := c.sections[sectionName]
+	if !ok {
+		return nil, badRequest(http.MethodPost, "/start", fmt.Sprintf("Section %s not found", sectionName))
+	}
+	id := newAnim.Id
+	if id == "" {
+		c.nextId++
+		id = strconv.Itoa(c.nextId)
+	}
+	runCount := newAnim.RunCount
+	if runCount == 0 {
+		runCount = info.RunCountDefault
+	}
+	colors := make([]*als.PreparedColorContainer, 0, len(newAnim.Colors))
+	for _, color := range newAnim.Colors {
+		colors = append(colors, color.Prepare(len(section.Pixels)))
+	}
+	params := als.NewRunningAnimationParams(info.Name, colors, id, sectionName, runCount,
+		newAnim.IntParams, newAnim.DoubleParams, newAnim.StringParams, newAnim.LocationParams,
+		newAnim.DistanceParams, newAnim.RotationParams, newAnim.EquationParams, newAnim)
+	c.running[id] = params
+	c.publish(&als.Event{Type: als.AnimationStarted, Animation: params.Copy()})
+	return params.Copy(), nil
+}
+
+func (c *Client) GetStripInfo() (*als.StripInfo, error) {
+	return c.GetStripInfoContext(context.Background())
+}
+
+func (c *Client) GetStripInfoContext(ctx context.Context) (*als.StripInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info := c.stripInfo
+	return &info, nil
+}
+
+func (c *Client) GetCurrentStripColor() ([]int, error) {
+	return c.GetCurrentStripColorContext(context.Background())
+}
+
+func (c *Client) GetCurrentStripColorContext(ctx context.Context) ([]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int(nil), c.stripColor...), nil
+}
+
+func (c *Client) ClearStrip() error {
+	return c.ClearStripContext(context.Background())
+}
+
+func (c *Client) ClearStripContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stripColor = make([]int, c.stripInfo.NumLEDs)
+	c.publish(&als.Event{Type: als.StripCleared})
+	return nil
+}
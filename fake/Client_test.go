@@ -0,0 +1,231 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Animations(t *testing.T) {
+	c := NewClient(10, &als.AnimationInfo{Name: "Meteor"}, &als.AnimationInfo{Name: "Color"})
+
+	names, err := c.GetSupportedAnimationsNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Color", "Meteor"}, names)
+
+	infos, _ := c.GetSupportedAnimations()
+	assert.Len(t, infos, 2)
+	assert.Equal(t, "Color", infos[0].Name)
+
+	infoMap, _ := c.GetSupportedAnimationsMap()
+	assert.Contains(t, infoMap, "Meteor")
+
+	info, err := c.GetAnimationInfo("Meteor")
+	assert.Nil(t, err)
+	assert.Equal(t, "Meteor", info.Name)
+
+	_, err = c.GetAnimationInfo("Missing")
+	assert.True(t, errors.Is(err, als.ErrNotFound))
+}
+
+func TestClient_AnimationsAreCopies(t *testing.T) {
+	c := NewClient(10, &als.AnimationInfo{Name: "Meteor", MinimumColors: 1,
+		IntParams: []*als.AnimationParameter{{Name: "spacing", Default: 3}}})
+
+	info, _ := c.GetAnimationInfo("Meteor")
+	info.MinimumColors = 5
+	info.IntParams[0].Default = 10
+	infos, _ := c.GetSupportedAnimations()
+	infos[0].IntParams = nil
+	infoMap, _ := c.GetSupportedAnimationsMap()
+	infoMap["Meteor"].Name = "Changed"
+
+	info, _ = c.GetAnimationInfo("Meteor")
+	assert.Equal(t, "Meteor", info.Name)
+	assert.Equal(t, 1, info.MinimumColors)
+	spacing, _ := info.IntParams[0].IntDefault()
+	assert.Equal(t, 3, spacing)
+}
+
+func TestClient_RunningAnimations(t *testing.T) {
+	c := NewClient(10, &als.AnimationInfo{Name: "Color", RunCountDefault: -1})
+
+	params, err := c.StartAnimation(&als.AnimationToRunParams{
+		Animation: "Color",
+		Colors:    []*als.ColorContainer{als.NewColorContainer([]int{0xFF})},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", params.Id)
+	assert.Equal(t, "fullStrip", params.Section)
+	assert.Equal(t, -1, params.RunCount)
+	assert.Equal(t, []int{0xFF}, params.Colors[0].OriginalColors)
+	assert.Len(t, params.Colors[0].Colors, 10)
+
+	_, err = c.StartAnimation(&als.AnimationToRunParams{Animation: "Color", Id: "custom", RunCount: 2})
+	assert.Nil(t, err)
+
+	ids, _ := c.GetRunningAnimationsIds()
+	assert.Equal(t, []string{"1", "custom"}, ids)
+
+	running, _ := c.GetRunningAnimations()
+	assert.Len(t, running, 2)
+	assert.Equal(t, 2, running["custom"].RunCount)
+
+	p, err := c.GetRunningAnimationParams("custom")
+	assert.Nil(t, err)
+	assert.Equal(t, "Color", p.AnimationName)
+
+	ended, err := c.EndAnimationFromParams(p)
+	assert.Nil(t, err)
+	assert.Equal(t, "custom", ended.Id)
+
+	_, err = c.EndAnimation("custom")
+	assert.True(t, errors.Is(err, als.ErrNotFound))
+	_, err = c.GetRunningAnimationParams("custom")
+	assert.True(t, errors.Is(err, als.ErrNotFound))
+
+	_, err = c.StartAnimation(&als.AnimationToRunParams{Animation: "Missing"})
+	assert.True(t, errors.Is(err, als.ErrBadRequest))
+	_, err = c.StartAnimation(&als.AnimationToRunParams{Animation: "Color", Section: "missing"})
+	assert.True(t, errors.Is(err, als.ErrBadRequest))
+}
+
+func TestClient_RunningAnimationsAreCopies(t *testing.T) {
+	c := NewClient(10, &als.AnimationInfo{Name: "Color"})
+
+	newAnim := &als.AnimationToRunParams{
+		Animation: "Color",
+		Id:        "a",
+		Colors:    []*als.ColorContainer{als.NewColorContainer([]int{0xFF})},
+		IntParams: map[string]int{"spacing": 3},
+	}
+	started, err := c.StartAnimation(newAnim)
+	assert.Nil(t, err)
+	newAnim.IntParams["spacing"] = 5
+	newAnim.Colors[0].Colors[0] = 0xFF00
+	started.IntParams["spacing"] = 6
+	started.SourceParams.Animation = "Changed"
+
+	running, _ := c.GetRunningAnimations()
+	running["a"].Colors[0].OriginalColors[0] = 0
+	running["a"].SourceParams.IntParams["spacing"] = 7
+
+	p, _ := c.GetRunningAnimationParams("a")
+	assert.Equal(t, 3, p.IntParams["spacing"])
+	assert.Equal(t, []int{0xFF}, p.Colors[0].OriginalColors)
+	assert.Equal(t, "Color", p.SourceParams.Animation)
+	assert.Equal(t, 3, p.SourceParams.IntParams["spacing"])
+	assert.Equal(t, []int{0xFF}, p.SourceParams.Colors[0].Colors)
+}
+
+func TestClient_Sections(t *testing.T) {
+	c := NewClient(5)
+
+	full, err := c.GetFullStripSection()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, full.Pixels)
+
+	sect, err := c.CreateNewSection(als.NewSection("left", []int{0, 1}, ""))
+	assert.Nil(t, err)
+	assert.Equal(t, "fullStrip", sect.ParentSectionName)
+
+	sect.Pixels[0] = 4
+	stored, _ := c.GetSection("left")
+	assert.Equal(t, []int{0, 1}, stored.Pixels)
+
+	sects, _ := c.GetSections()
+	assert.Len(t, sects, 2)
+	assert.Equal(t, "fullStrip", sects[0].Name)
+
+	sectMap, _ := c.GetSectionsMap()
+	assert.Contains(t, sectMap, "left")
+
+	_, err = c.CreateNewSection(als.NewSection("bad", []int{5}, ""))
+	assert.True(t, errors.Is(err, als.ErrBadRequest))
+	_, err = c.CreateNewSection(als.NewSection("bad", []int{0}, "missing"))
+	assert.True(t, errors.Is(err, als.ErrBadRequest))
+	_, err = c.GetSection("bad")
+	assert.True(t, errors.Is(err, als.ErrNotFound))
+}
+
+func TestClient_Strip(t *testing.T) {
+	c := NewClient(3)
+
+	info, err := c.GetStripInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, info.NumLEDs)
+
+	color, _ := c.GetCurrentStripColor()
+	assert.Equal(t, []int{0, 0, 0}, color)
+
+	c.SetStripColor([]int{1, 2, 3})
+	color, _ = c.GetCurrentStripColor()
+	assert.Equal(t, []int{1, 2, 3}, color)
+
+	assert.Nil(t, c.ClearStrip())
+	color, _ = c.GetCurrentStripColor()
+	assert.Equal(t, []int{0, 0, 0}, color)
+}
+
+func TestClient_ContextCanceled(t *testing.T) {
+	c := NewClient(3)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.GetStripInfoContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, c.ClearStripContext(ctx))
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	c := NewClient(3, &als.AnimationInfo{Name: "Color"})
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := c.Subscribe(ctx, nil)
+	assert.Nil(t, err)
+
+	_, _ = c.StartAnimation(&als.AnimationToRunParams{Animation: "Color", Id: "a"})
+	_, _ = c.EndAnimation("a")
+	_, _ = c.CreateNewSection(als.NewSection("s", []int{0}, ""))
+	_ = c.ClearStrip()
+
+	var types []als.EventType
+	for i := 0; i < 4; i++ {
+		select {
+		case event := <-events:
+			types = append(types, event.Type)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+	assert.Equal(t, []als.EventType{als.AnimationStarted, als.AnimationEnded, als.SectionCreated, als.StripCleared}, types)
+
+	cancel()
+	for range events {
+	}
+}
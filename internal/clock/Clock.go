@@ -0,0 +1,101 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+// Package clock provides the time to the recording and schedule packages,
+// with a fake that tests can move by hand
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the time, so tests can control it
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real is the system clock
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+func (Real) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// Fake only moves when Advance is called
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+	waiting chan struct{}
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, waiting: make(chan struct{}, 100)}
+}
+
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Fake) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, waiter{deadline: c.now.Add(d), ch: ch})
+	c.waiting <- struct{}{}
+	return ch
+}
+
+// Waiting receives once for every call to After, so a test can tell when the
+// code under test is waiting for the clock
+func (c *Fake) Waiting() <-chan struct{} {
+	return c.waiting
+}
+
+// Advance moves the clock forward by d, firing any After channels that are due
+func (c *Fake) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			remaining = append(remaining, w)
+		} else {
+			w.ch <- c.now
+		}
+	}
+	c.waiters = remaining
+}
@@ -0,0 +1,60 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2021, 3, 14, 12, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+	assert.Equal(t, start, c.Now())
+
+	soon := c.After(time.Second)
+	later := c.After(time.Minute)
+	<-c.Waiting()
+	<-c.Waiting()
+
+	c.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), <-soon)
+	select {
+	case <-later:
+		t.Fatal("later fired early")
+	default:
+	}
+
+	c.Advance(time.Minute)
+	assert.Equal(t, start.Add(61*time.Second), <-later)
+	assert.Equal(t, start.Add(61*time.Second), c.Now())
+}
+
+func TestReal(t *testing.T) {
+	var c Clock = Real{}
+	before := time.Now()
+	assert.False(t, c.Now().Before(before))
+	<-c.After(time.Millisecond)
+}
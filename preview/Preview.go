@@ -0,0 +1,224 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+// Package preview renders strip colors as ANSI terminal bars, PNG swatches
+// and animated GIFs
+package preview
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+)
+
+// Options controls how strip colors are rendered
+type Options struct {
+	// Sections, if set, renders one row per section with its pixels in their
+	// physical positions, ordered as a depth-first walk of the section tree.
+	// Otherwise a single row holds every pixel.
+	Sections map[string]*als.Section
+	// Columns limits the width of ANSI output by sampling pixels evenly.
+	// 0 renders one column per pixel.
+	Columns int
+	// PixelSize is the width and height in image pixels of each LED in PNG
+	// and GIF output. The default is 10.
+	PixelSize int
+}
+
+func (o *Options) pixelSize() int {
+	if o == nil || o.PixelSize <= 0 {
+		return 10
+	}
+	return o.PixelSize
+}
+
+// row is one line of a rendering: a label and a mask of the pixels shown
+type row struct {
+	label string
+	shown func(pixel int) bool
+}
+
+func rows(opts *Options) []row {
+	if opts == nil || len(opts.Sections) == 0 {
+		return []row{{shown: func(int) bool { return true }}}
+	}
+
+	var sections []*als.Section
+	if root, err := als.BuildSectionTree(opts.Sections); err == nil {
+		root.Walk(func(node *als.SectionNode, depth int) {
+			sections = append(sections, node.Section)
+		})
+	} else {
+		for _, sect := range opts.Sections {
+			sections = append(sections, sect)
+		}
+		sort.Slice(sections, func(i, j int) bool { return sections[i].Name < sections[j].Name })
+	}
+
+	result := make([]row, 0, len(sections))
+	for _, sect := range sections {
+		pixels := make(map[int]bool, len(sect.Pixels))
+		for _, p := range sect.Pixels {
+			pixels[p] = true
+		}
+		result = append(result, row{label: sect.Name, shown: func(pixel int) bool { return pixels[pixel] }})
+	}
+	return result
+}
+
+// ANSI renders colors as 24-bit ANSI terminal bars, one line per row
+func ANSI(colors []int, opts *Options) string {
+	columns := len(colors)
+	if opts != nil && opts.Columns > 0 && opts.Columns < columns {
+		columns = opts.Columns
+	}
+
+	rs := rows(opts)
+	labelWidth := 0
+	for _, r := range rs {
+		if len(r.label) > labelWidth {
+			labelWidth = len(r.label)
+		}
+	}
+
+	var b strings.Builder
+	for _, r := range rs {
+		if labelWidth > 0 {
+			fmt.Fprintf(&b, "%-*s ", labelWidth, r.label)
+		}
+		for col := 0; col < columns; col++ {
+			pixel := col * len(colors) / columns
+			if !r.shown(pixel) {
+				b.WriteString("\x1b[0m ")
+				continue
+			}
+			red, green, blue := als.SplitRGB(colors[pixel])
+			fmt.Fprintf(&b, "\x1b[48;2;%d;%d;%dm ", red, green, blue)
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	return b.String()
+}
+
+// Image renders colors as a swatch with one square per LED and one band of
+// squares per row. Pixels not in a row's section are transparent.
+func Image(colors []int, opts *Options) *image.RGBA {
+	size := opts.pixelSize()
+	rs := rows(opts)
+	img := image.NewRGBA(image.Rect(0, 0, len(colors)*size, len(rs)*size))
+	for y, r := range rs {
+		for x, c := range colors {
+			if !r.shown(x) {
+				continue
+			}
+			rect := image.Rect(x*size, y*size, (x+1)*size, (y+1)*size)
+			draw.Draw(img, rect, &image.Uniform{C: toRGBA(c)}, image.Point{}, draw.Src)
+		}
+	}
+	return img
+}
+
+// PNG writes colors as a PNG swatch
+func PNG(w io.Writer, colors []int, opts *Options) error {
+	return png.Encode(w, Image(colors, opts))
+}
+
+// GIF writes frames of strip colors as an animated GIF that shows each frame
+// for delay and loops forever
+func GIF(w io.Writer, frames [][]int, delay time.Duration, opts *Options) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to render")
+	}
+	anim := &gif.GIF{}
+	for _, frame := range frames {
+		img := Image(frame, opts)
+		paletted := image.NewPaletted(img.Bounds(), framePalette(frame))
+		draw.Draw(paletted, img.Bounds(), img, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, paletted)
+		// GIF delays are in hundredths of a second
+		anim.Delay = append(anim.Delay, int(delay/(10*time.Millisecond)))
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// framePalette returns a palette holding exactly the frame's colors plus
+// transparency when there are few enough, and a general palette otherwise
+func framePalette(frame []int) color.Palette {
+	p := color.Palette{color.Transparent}
+	seen := map[int]bool{}
+	for _, c := range frame {
+		if !seen[c] {
+			seen[c] = true
+			p = append(p, toRGBA(c))
+		}
+	}
+	if len(p) > 256 {
+		return palette.Plan9
+	}
+	return p
+}
+
+// Sample polls the client's strip color count times, interval apart, for
+// rendering with GIF
+func Sample(ctx context.Context, client als.Client, interval time.Duration, count int) ([][]int, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("negative sample count %d", count)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("sample interval must be positive, got %s", interval)
+	}
+	frames := make([][]int, 0, count)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for len(frames) < count {
+		colors, err := client.GetCurrentStripColorContext(ctx)
+		if err != nil {
+			return frames, err
+		}
+		frames = append(frames, colors)
+		if len(frames) == count {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return frames, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return frames, nil
+}
+
+func toRGBA(c int) color.RGBA {
+	r, g, b := als.SplitRGB(c)
+	return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 0xFF}
+}
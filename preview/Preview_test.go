@@ -0,0 +1,122 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package preview
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/fake"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestANSI(t *testing.T) {
+	out := ANSI([]int{0xFF0000, 0x00FF00}, nil)
+	assert.Equal(t, "\x1b[48;2;255;0;0m \x1b[48;2;0;255;0m \x1b[0m\n", out)
+
+	out = ANSI([]int{0xFF0000, 0x00FF00, 0x0000FF, 0xFFFFFF}, &Options{Columns: 2})
+	assert.Equal(t, "\x1b[48;2;255;0;0m \x1b[48;2;0;0;255m \x1b[0m\n", out)
+}
+
+func TestANSI_Sections(t *testing.T) {
+	sections := map[string]*als.Section{
+		"fullStrip": als.NewSectionFromRange("fullStrip", 0, 2, ""),
+		"end":       als.NewSectionFromRange("end", 2, 2, "fullStrip"),
+	}
+
+	out := ANSI([]int{0x010101, 0x020202, 0x030303}, &Options{Sections: sections})
+	assert.Equal(t, "fullStrip \x1b[48;2;1;1;1m \x1b[48;2;2;2;2m \x1b[48;2;3;3;3m \x1b[0m\n"+
+		"end       \x1b[0m \x1b[0m \x1b[48;2;3;3;3m \x1b[0m\n", out)
+}
+
+func TestPNG(t *testing.T) {
+	sections := map[string]*als.Section{
+		"fullStrip": als.NewSectionFromRange("fullStrip", 0, 1, ""),
+		"first":     als.NewSectionFromRange("first", 0, 0, "fullStrip"),
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, PNG(&buf, []int{0xFF0000, 0x0000FF}, &Options{Sections: sections, PixelSize: 4}))
+	img, err := png.Decode(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, img.Bounds().Dx())
+	assert.Equal(t, 8, img.Bounds().Dy())
+	assert.Equal(t, color.RGBA{R: 0xFF, A: 0xFF}, color.RGBAModel.Convert(img.At(0, 0)))
+	assert.Equal(t, color.RGBA{B: 0xFF, A: 0xFF}, color.RGBAModel.Convert(img.At(7, 3)))
+	assert.Equal(t, color.RGBA{}, color.RGBAModel.Convert(img.At(7, 7)))
+}
+
+func TestGIF(t *testing.T) {
+	frames := [][]int{{0xFF0000, 0}, {0, 0xFF0000}}
+
+	var buf bytes.Buffer
+	assert.Nil(t, GIF(&buf, frames, 100*time.Millisecond, &Options{PixelSize: 1}))
+	anim, err := gif.DecodeAll(&buf)
+	assert.Nil(t, err)
+	assert.Len(t, anim.Image, 2)
+	assert.Equal(t, []int{10, 10}, anim.Delay)
+	r, _, _, _ := anim.Image[1].At(1, 0).RGBA()
+	assert.Equal(t, uint32(0xFFFF), r)
+
+	assert.NotNil(t, GIF(&buf, nil, time.Second, nil))
+}
+
+func TestGIF_ManyColors(t *testing.T) {
+	frame := make([]int, 300)
+	for i := range frame {
+		frame[i] = i * 0x010203
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, GIF(&buf, [][]int{frame}, time.Second, &Options{PixelSize: 1}))
+	_, err := gif.DecodeAll(&buf)
+	assert.Nil(t, err)
+}
+
+func TestSample(t *testing.T) {
+	client := fake.NewClient(3)
+	client.SetStripColor([]int{1, 2, 3})
+
+	frames, err := Sample(context.Background(), client, time.Millisecond, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]int{{1, 2, 3}, {1, 2, 3}, {1, 2, 3}}, frames)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Sample(ctx, client, time.Millisecond, 3)
+	assert.NotNil(t, err)
+
+	_, err = Sample(context.Background(), client, time.Millisecond, -1)
+	assert.NotNil(t, err)
+	_, err = Sample(context.Background(), client, 0, 3)
+	assert.NotNil(t, err)
+	frames, err = Sample(context.Background(), client, time.Millisecond, 0)
+	assert.Nil(t, err)
+	assert.Len(t, frames, 0)
+}
@@ -0,0 +1,122 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package recording
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/internal/clock"
+)
+
+// Clock provides the time to a Recorder, so tests can control it
+type Clock = clock.Clock
+
+// RecorderOption configures a Recorder
+type RecorderOption func(*Recorder)
+
+// WithClock sets the clock used to schedule samples
+func WithClock(clock Clock) RecorderOption {
+	return func(r *Recorder) {
+		r.clock = clock
+	}
+}
+
+// WithLogger sets the logger that failed samples are reported to
+func WithLogger(logger als.Logger) RecorderOption {
+	return func(r *Recorder) {
+		r.logger = logger
+	}
+}
+
+// Recorder samples a strip's colors at a fixed interval
+type Recorder struct {
+	client   als.Client
+	interval time.Duration
+	clock    Clock
+	logger   als.Logger
+}
+
+func NewRecorder(client als.Client, interval time.Duration, opts ...RecorderOption) *Recorder {
+	r := &Recorder{client: client, interval: interval, clock: clock.Real{}}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Record writes a recording to w until ctx is done, returning the number of
+// frames written. Samples are scheduled from the start time so they don't
+// drift, samples missed while a request was slow are skipped, and samples
+// that fail are logged and skipped.
+func (r *Recorder) Record(ctx context.Context, w io.Writer) (int, error) {
+	if r.interval <= 0 {
+		return 0, fmt.Errorf("invalid sample interval %s", r.interval)
+	}
+	info, err := r.client.GetStripInfoContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	start := r.clock.Now()
+	writer, err := NewWriter(w, Header{StripInfo: info, Start: start, Interval: r.interval})
+	if err != nil {
+		return 0, err
+	}
+
+	frames := 0
+	for next := time.Duration(0); ; next += r.interval {
+		elapsed := r.clock.Now().Sub(start)
+		if elapsed-next >= r.interval {
+			// Skip the samples missed while a slow request was running
+			next = elapsed - elapsed%r.interval
+		}
+		if wait := next - elapsed; wait > 0 {
+			select {
+			case <-ctx.Done():
+				return frames, writer.Flush()
+			case <-r.clock.After(wait):
+			}
+		}
+		if ctx.Err() != nil {
+			return frames, writer.Flush()
+		}
+
+		colors, err := r.client.GetCurrentStripColorContext(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return frames, writer.Flush()
+			}
+			if r.logger != nil {
+				r.logger.Warn("strip color sample failed", "offset", next, "error", err)
+			}
+			continue
+		}
+		if err := writer.WriteFrame(r.clock.Now().Sub(start), colors); err != nil {
+			return frames, err
+		}
+		frames++
+	}
+}
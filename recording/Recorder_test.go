@@ -0,0 +1,95 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package recording
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AnimatedLEDStrip/client-go/fake"
+	"github.com/AnimatedLEDStrip/client-go/internal/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder_Record(t *testing.T) {
+	client := fake.NewClient(3)
+	client.SetStripColor([]int{0xFF0000, 0, 0})
+	clk := clock.NewFake(time.Date(2021, 3, 14, 12, 0, 0, 0, time.UTC))
+	start := clk.Now()
+	recorder := NewRecorder(client, 100*time.Millisecond, WithClock(clk))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var buf bytes.Buffer
+	type result struct {
+		frames int
+		err    error
+	}
+	done := make(chan result)
+	go func() {
+		frames, err := recorder.Record(ctx, &buf)
+		done <- result{frames, err}
+	}()
+
+	<-clk.Waiting()
+	client.SetStripColor([]int{0, 0xFF00, 0})
+	clk.Advance(100 * time.Millisecond)
+	<-clk.Waiting()
+	clk.Advance(100 * time.Millisecond)
+	<-clk.Waiting()
+	client.SetStripColor([]int{0, 0, 0xFF})
+	clk.Advance(100 * time.Millisecond)
+	<-clk.Waiting()
+	cancel()
+
+	res := <-done
+	assert.Nil(t, res.err)
+	assert.Equal(t, 4, res.frames)
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, r.Header().StripInfo.NumLEDs)
+	assert.True(t, start.Equal(r.Header().Start))
+	assert.Equal(t, 100*time.Millisecond, r.Header().Interval)
+	assert.Equal(t, 4, r.Len())
+	assert.Equal(t, 300*time.Millisecond, r.Duration())
+
+	var offsets []time.Duration
+	var colors [][]int
+	for {
+		frame, err := r.Next()
+		if err != nil {
+			break
+		}
+		offsets = append(offsets, frame.Offset)
+		colors = append(colors, frame.Colors)
+	}
+	assert.Equal(t, []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}, offsets)
+	assert.Equal(t, [][]int{{0xFF0000, 0, 0}, {0, 0xFF00, 0}, {0, 0xFF00, 0}, {0, 0, 0xFF}}, colors)
+}
+
+func TestRecorder_InvalidInterval(t *testing.T) {
+	_, err := NewRecorder(fake.NewClient(1), 0).Record(context.Background(), &bytes.Buffer{})
+	assert.NotNil(t, err)
+}
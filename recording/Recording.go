@@ -0,0 +1,396 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+// Package recording records the colors a strip displays to a compact file
+// and reads them back for replay and export
+package recording
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/preview"
+)
+
+// The file starts with magic, a version byte and a length-prefixed JSON
+// Header. Each frame is a uvarint offset in nanoseconds from Header.Start and
+// a type byte. Full frames follow with a uvarint pixel count and 3 bytes of
+// RGB per pixel; repeat frames have the same colors as the previous frame and
+// no payload.
+const (
+	magic   = "ALSREC"
+	version = 1
+
+	fullFrame   = 0
+	repeatFrame = 1
+
+	// maxHeaderSize and maxPixels bound what a reader will allocate for a
+	// corrupt file
+	maxHeaderSize = 1 << 20
+	maxPixels     = 1 << 20
+)
+
+// ErrFormat is returned when a file is not a valid recording
+var ErrFormat = errors.New("not a valid recording")
+
+// Header describes a recording
+type Header struct {
+	StripInfo *als.StripInfo `json:"stripInfo"`
+	Start     time.Time      `json:"start"`
+	Interval  time.Duration  `json:"interval"`
+}
+
+// Frame is the strip's colors at a point in the recording
+type Frame struct {
+	// Offset is the time since Header.Start
+	Offset time.Duration
+	Colors []int
+}
+
+// Writer writes frames to a recording
+type Writer struct {
+	w        *bufio.Writer
+	previous []int
+	last     time.Duration
+	buf      []byte
+}
+
+// NewWriter writes the header to w and returns a Writer for its frames
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	data, err := json.Marshal(&header)
+	if err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString(magic)
+	bw.WriteByte(version)
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	bw.Write(length[:])
+	if _, err := bw.Write(data); err != nil {
+		return nil, err
+	}
+	return &Writer{w: bw, last: -1, buf: make([]byte, binary.MaxVarintLen64)}, nil
+}
+
+// WriteFrame appends a frame. Offsets must not decrease.
+func (w *Writer) WriteFrame(offset time.Duration, colors []int) error {
+	if offset < 0 || offset < w.last {
+		return fmt.Errorf("frame offset %s is before the previous frame", offset)
+	}
+	if len(colors) > maxPixels {
+		return fmt.Errorf("frame has %d pixels, more than the %d a recording can hold", len(colors), maxPixels)
+	}
+	w.last = offset
+	n := binary.PutUvarint(w.buf, uint64(offset))
+	w.w.Write(w.buf[:n])
+
+	if w.previous != nil && equal(w.previous, colors) {
+		return w.w.WriteByte(repeatFrame)
+	}
+	w.w.WriteByte(fullFrame)
+	n = binary.PutUvarint(w.buf, uint64(len(colors)))
+	w.w.Write(w.buf[:n])
+	for _, c := range colors {
+		r, g, b := als.SplitRGB(c)
+		w.w.Write([]byte{byte(r), byte(g), byte(b)})
+	}
+	w.previous = append(w.previous[:0], colors...)
+	return nil
+}
+
+// Flush writes any buffered frames to the underlying writer
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+type indexEntry struct {
+	offset time.Duration
+	// pos is the file position of the full frame holding this frame's colors
+	pos int64
+}
+
+// Reader reads a recording. It indexes the frames when opened so it can
+// seek by time.
+type Reader struct {
+	r      io.ReadSeeker
+	closer io.Closer
+	header Header
+	index  []indexEntry
+	next   int
+}
+
+// Open opens a recording file
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// NewReader reads the header and indexes the frames of the recording in r
+func NewReader(r io.ReadSeeker) (*Reader, error) {
+	br := &countingReader{r: bufio.NewReader(r)}
+
+	head := make([]byte, len(magic)+1+4)
+	if _, err := io.ReadFull(br, head); err != nil || string(head[:len(magic)]) != magic {
+		return nil, ErrFormat
+	}
+	if head[len(magic)] != version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrFormat, head[len(magic)])
+	}
+	length := binary.BigEndian.Uint32(head[len(magic)+1:])
+	if length > maxHeaderSize {
+		return nil, fmt.Errorf("%w: header is %d bytes", ErrFormat, length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(br, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	reader := &Reader{r: r}
+	if err := json.Unmarshal(data, &reader.header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+
+	fullPos := int64(-1)
+	for {
+		offset, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+		}
+		frameType, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: truncated frame", ErrFormat)
+		}
+		switch frameType {
+		case fullFrame:
+			fullPos = br.n
+			count, err := binary.ReadUvarint(br)
+			if err != nil {
+				return nil, fmt.Errorf("%w: truncated frame", ErrFormat)
+			}
+			if count > maxPixels {
+				return nil, fmt.Errorf("%w: frame has %d pixels", ErrFormat, count)
+			}
+			if _, err := io.CopyN(ioutil.Discard, br, int64(count)*3); err != nil {
+				return nil, fmt.Errorf("%w: truncated frame", ErrFormat)
+			}
+		case repeatFrame:
+			if fullPos < 0 {
+				return nil, fmt.Errorf("%w: repeat frame without a previous frame", ErrFormat)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown frame type %d", ErrFormat, frameType)
+		}
+		reader.index = append(reader.index, indexEntry{offset: time.Duration(offset), pos: fullPos})
+	}
+	return reader, nil
+}
+
+// Header returns the recording's header
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Len returns the number of frames
+func (r *Reader) Len() int {
+	return len(r.index)
+}
+
+// Duration returns the offset of the last frame
+func (r *Reader) Duration() time.Duration {
+	if len(r.index) == 0 {
+		return 0
+	}
+	return r.index[len(r.index)-1].offset
+}
+
+// Next returns the next frame, or io.EOF after the last one
+func (r *Reader) Next() (*Frame, error) {
+	if r.next >= len(r.index) {
+		return nil, io.EOF
+	}
+	frame, err := r.Frame(r.next)
+	if err != nil {
+		return nil, err
+	}
+	r.next++
+	return frame, nil
+}
+
+// Frame returns the frame at index i
+func (r *Reader) Frame(i int) (*Frame, error) {
+	if i < 0 || i >= len(r.index) {
+		return nil, fmt.Errorf("frame %d out of range", i)
+	}
+	entry := r.index[i]
+	if _, err := r.r.Seek(entry.pos, io.SeekStart); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r.r)
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxPixels {
+		return nil, fmt.Errorf("%w: frame has %d pixels", ErrFormat, count)
+	}
+	rgb := make([]byte, count*3)
+	if _, err := io.ReadFull(br, rgb); err != nil {
+		return nil, err
+	}
+	colors := make([]int, count)
+	for p := range colors {
+		colors[p] = als.RGB(int(rgb[p*3]), int(rgb[p*3+1]), int(rgb[p*3+2]))
+	}
+	return &Frame{Offset: entry.offset, Colors: colors}, nil
+}
+
+// Seek positions the reader so Next returns the frame being displayed at
+// offset, which is the last frame at or before it
+func (r *Reader) Seek(offset time.Duration) {
+	i := sort.Search(len(r.index), func(i int) bool { return r.index[i].offset > offset })
+	if i > 0 {
+		i--
+	}
+	r.next = i
+}
+
+// At returns the frame being displayed at offset
+func (r *Reader) At(offset time.Duration) (*Frame, error) {
+	r.Seek(offset)
+	return r.Next()
+}
+
+// Close closes the file if the reader was created with Open
+func (r *Reader) Close() error {
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// WriteCSV writes every frame as a row of the offset in milliseconds followed
+// by each pixel's color as #RRGGBB
+func (r *Reader) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	numLEDs := 0
+	if r.header.StripInfo != nil {
+		numLEDs = r.header.StripInfo.NumLEDs
+	}
+	header := []string{"offset_ms"}
+	for p := 0; p < numLEDs; p++ {
+		header = append(header, "pixel"+strconv.Itoa(p))
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for i := range r.index {
+		frame, err := r.Frame(i)
+		if err != nil {
+			return err
+		}
+		row := []string{strconv.FormatInt(frame.Offset.Milliseconds(), 10)}
+		for _, c := range frame.Colors {
+			row = append(row, als.ColorHex(c))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteGIF renders the recording as an animated GIF, sampling the frame
+// displayed at each step of the recording's interval
+func (r *Reader) WriteGIF(w io.Writer, opts *preview.Options) error {
+	if len(r.index) == 0 {
+		return errors.New("recording has no frames")
+	}
+	step := r.header.Interval
+	if step <= 0 {
+		step = 100 * time.Millisecond
+	}
+	var frames [][]int
+	for offset := time.Duration(0); offset <= r.Duration(); offset += step {
+		frame, err := r.At(offset)
+		if err != nil {
+			return err
+		}
+		frames = append(frames, frame.Colors)
+	}
+	return preview.GIF(w, frames, step, opts)
+}
+
+func equal(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// countingReader tracks the position in the underlying stream
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
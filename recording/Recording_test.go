@@ -0,0 +1,153 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package recording
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image/gif"
+	"io"
+	"testing"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRecording(t *testing.T) *Reader {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Header{
+		StripInfo: &als.StripInfo{NumLEDs: 2},
+		Start:     time.Date(2021, 3, 14, 12, 0, 0, 0, time.UTC),
+		Interval:  time.Second,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, w.WriteFrame(0, []int{0xFF0000, 0}))
+	assert.Nil(t, w.WriteFrame(time.Second, []int{0xFF0000, 0}))
+	assert.Nil(t, w.WriteFrame(2500*time.Millisecond, []int{0, 0x0000FF}))
+	assert.NotNil(t, w.WriteFrame(time.Second, []int{0, 0}))
+	assert.Nil(t, w.Flush())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	return r
+}
+
+func TestWriter_Compact(t *testing.T) {
+	var full, repeated bytes.Buffer
+	w1, _ := NewWriter(&full, Header{})
+	_ = w1.WriteFrame(0, make([]int, 100))
+	_ = w1.Flush()
+	w2, _ := NewWriter(&repeated, Header{})
+	_ = w2.WriteFrame(0, make([]int, 100))
+	_ = w2.WriteFrame(time.Second, make([]int, 100))
+	_ = w2.Flush()
+
+	// A repeated frame costs only its offset (5 bytes for 1s) and type
+	assert.Equal(t, full.Len()+6, repeated.Len())
+}
+
+func TestReader_Seek(t *testing.T) {
+	r := testRecording(t)
+	assert.Equal(t, 3, r.Len())
+
+	frame, err := r.At(1800 * time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Second, frame.Offset)
+	assert.Equal(t, []int{0xFF0000, 0}, frame.Colors)
+
+	frame, err = r.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 0x0000FF}, frame.Colors)
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+
+	frame, _ = r.At(time.Hour)
+	assert.Equal(t, 2500*time.Millisecond, frame.Offset)
+	r.Seek(0)
+	frame, _ = r.Next()
+	assert.Equal(t, time.Duration(0), frame.Offset)
+
+	_, err = r.Frame(3)
+	assert.NotNil(t, err)
+}
+
+func TestReader_WriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, testRecording(t).WriteCSV(&buf))
+	assert.Equal(t, "offset_ms,pixel0,pixel1\n"+
+		"0,#FF0000,#000000\n"+
+		"1000,#FF0000,#000000\n"+
+		"2500,#000000,#0000FF\n", buf.String())
+}
+
+func TestReader_WriteGIF(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, testRecording(t).WriteGIF(&buf, nil))
+	anim, err := gif.DecodeAll(&buf)
+	assert.Nil(t, err)
+	// Sampled at 0s, 1s and 2s
+	assert.Len(t, anim.Image, 3)
+	assert.Equal(t, []int{100, 100, 100}, anim.Delay)
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	_, err := NewReader(bytes.NewReader([]byte("not a recording")))
+	assert.True(t, errors.Is(err, ErrFormat))
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, Header{})
+	_ = w.WriteFrame(0, []int{1, 2, 3})
+	_ = w.Flush()
+	_, err = NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-2]))
+	assert.True(t, errors.Is(err, ErrFormat))
+
+	data := append([]byte(nil), buf.Bytes()...)
+	data[len(magic)] = 9
+	_, err = NewReader(bytes.NewReader(data))
+	assert.True(t, errors.Is(err, ErrFormat))
+}
+
+func TestNewReader_Corrupt(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, Header{})
+	_ = w.Flush()
+	header := buf.Bytes()
+
+	// A huge header length is rejected before it is allocated
+	data := append([]byte(nil), header...)
+	binary.BigEndian.PutUint32(data[len(magic)+1:], 1<<32-1)
+	_, err := NewReader(bytes.NewReader(data))
+	assert.True(t, errors.Is(err, ErrFormat))
+
+	// So is a pixel count that would overflow the frame size
+	data = append([]byte(nil), header...)
+	data = append(data, 0, fullFrame)
+	data = append(data, make([]byte, binary.MaxVarintLen64)...)
+	n := binary.PutUvarint(data[len(header)+2:], 1<<62)
+	_, err = NewReader(bytes.NewReader(data[:len(header)+2+n]))
+	assert.True(t, errors.Is(err, ErrFormat))
+
+	assert.NotNil(t, w.WriteFrame(0, make([]int, maxPixels+1)))
+}
@@ -0,0 +1,294 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package scene
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+)
+
+type command int
+
+const (
+	pauseCommand command = iota
+	resumeCommand
+	skipCommand
+)
+
+// Status describes what a Runner is playing
+type Status struct {
+	Playlist string
+	// Entry is the index of the current entry in the playlist, or -1
+	Entry  int
+	Scene  string
+	Paused bool
+}
+
+// Runner plays playlists against a server. Pause, Resume and Skip can be
+// called from any goroutine while Run is playing.
+type Runner struct {
+	client   als.Client
+	file     *File
+	commands chan command
+
+	mu     sync.Mutex
+	status Status
+}
+
+func NewRunner(client als.Client, file *File) *Runner {
+	return &Runner{client: client, file: file, commands: make(chan command, 8), status: Status{Entry: -1}}
+}
+
+// Pause stops the current scene's timer; its animations keep running
+func (r *Runner) Pause() {
+	r.send(pauseCommand)
+}
+
+// Resume continues the current scene's timer after a Pause
+func (r *Runner) Resume() {
+	r.send(resumeCommand)
+}
+
+// Skip moves to the next entry in the playlist
+func (r *Runner) Skip() {
+	r.send(skipCommand)
+}
+
+// send queues a command for the playing entry, dropping it if too many are queued
+func (r *Runner) send(cmd command) {
+	select {
+	case r.commands <- cmd:
+	default:
+	}
+}
+
+// Status returns what the runner is currently playing
+func (r *Runner) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+func (r *Runner) setStatus(update func(s *Status)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	update(&r.status)
+}
+
+// Run plays the named playlist until it finishes or ctx is done. The last
+// scene's animations are ended when Run returns.
+func (r *Runner) Run(ctx context.Context, playlistName string) error {
+	playlist := r.file.Playlist(playlistName)
+	if playlist == nil {
+		return fmt.Errorf("unknown playlist %q", playlistName)
+	}
+	if len(playlist.Entries) == 0 {
+		return fmt.Errorf("playlist %s has no entries", playlistName)
+	}
+	r.setStatus(func(s *Status) { *s = Status{Playlist: playlist.Name, Entry: -1} })
+	defer r.setStatus(func(s *Status) { *s = Status{Entry: -1} })
+
+	var running []string
+	defer func() {
+		// Clean up even if ctx is done
+		_ = r.end(context.Background(), running)
+	}()
+
+	for pass := 0; playlist.Repeat < 0 || pass <= playlist.Repeat; pass++ {
+		for i, entry := range playlist.Entries {
+			if entry == nil {
+				return fmt.Errorf("playlist %s entry %d is empty", playlist.Name, i)
+			}
+			r.setStatus(func(s *Status) {
+				s.Entry = i
+				s.Scene = entry.Scene
+			})
+
+			previous := running
+			if entry.Transition != Replace {
+				if err := r.end(ctx, previous); err != nil {
+					return err
+				}
+				previous = nil
+			}
+			scene := r.file.Scene(entry.Scene)
+			if scene == nil {
+				return fmt.Errorf("playlist %s entry %d: unknown scene %q", playlist.Name, i, entry.Scene)
+			}
+			var err error
+			running, err = StartScene(ctx, r.client, scene)
+			if err != nil {
+				running = append(running, previous...)
+				return err
+			}
+
+			// An id the new scene reuses now belongs to it
+			previous = without(previous, running)
+			remaining := time.Duration(entry.Duration)
+			if len(previous) > 0 {
+				fade := time.Duration(entry.Fade)
+				if remaining > 0 && fade > remaining {
+					fade = remaining
+				}
+				// Without a fade, the previous scene ends as soon as this one starts
+				var skipped bool
+				var err error
+				if fade > 0 {
+					skipped, err = r.wait(ctx, fade)
+				}
+				if err == nil {
+					err = r.end(ctx, previous)
+				}
+				if err != nil {
+					running = append(running, previous...)
+					return err
+				}
+				if skipped {
+					continue
+				}
+				if remaining > 0 {
+					remaining -= fade
+					if remaining == 0 {
+						continue
+					}
+				}
+			}
+			if _, err := r.wait(ctx, remaining); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// end ends the animations by id, ignoring any that have already ended
+func (r *Runner) end(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if _, err := r.client.EndAnimationContext(ctx, id); err != nil && !errors.Is(err, als.ErrNotFound) {
+			return fmt.Errorf("ending %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// without returns the ids that aren't in exclude
+func without(ids []string, exclude []string) []string {
+	excluded := make(map[string]bool, len(exclude))
+	for _, id := range exclude {
+		excluded[id] = true
+	}
+	var kept []string
+	for _, id := range ids {
+		if !excluded[id] {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// wait waits for d, not counting time spent paused, returning early with
+// skipped set if Skip is called. A d of 0 waits until Skip is called.
+func (r *Runner) wait(ctx context.Context, d time.Duration) (bool, error) {
+	remaining := d
+	paused := false
+	for {
+		var timeout <-chan time.Time
+		var timer *time.Timer
+		started := time.Now()
+		if !paused && d > 0 {
+			timer = time.NewTimer(remaining)
+			timeout = timer.C
+		}
+
+		var cmd command
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return false, ctx.Err()
+		case <-timeout:
+			return false, nil
+		case cmd = <-r.commands:
+			stopTimer(timer)
+			if !paused {
+				remaining -= time.Since(started)
+			}
+		}
+
+		switch cmd {
+		case skipCommand:
+			r.setStatus(func(s *Status) { s.Paused = false })
+			return true, nil
+		case pauseCommand:
+			if !paused {
+				paused = true
+				r.setStatus(func(s *Status) { s.Paused = true })
+			}
+		case resumeCommand:
+			if paused {
+				paused = false
+				r.setStatus(func(s *Status) { s.Paused = false })
+			}
+		}
+	}
+}
+
+func stopTimer(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
+
+// StartScene creates any of the scene's sections that are missing on the
+// server and starts its animations, returning their ids. If an animation
+// fails to start, the ids of those already started are returned with the error.
+func StartScene(ctx context.Context, client als.Client, scene *Scene) ([]string, error) {
+	if len(scene.Sections) > 0 {
+		existing, err := client.GetSectionsMapContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, sect := range scene.Sections {
+			if _, ok := existing[sect.Name]; ok {
+				continue
+			}
+			if _, err := client.CreateNewSectionContext(ctx, sect); err != nil {
+				return nil, fmt.Errorf("scene %s: creating section %s: %w", scene.Name, sect.Name, err)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(scene.Animations))
+	for _, anim := range scene.Animations {
+		params, err := client.StartAnimationContext(ctx, anim)
+		if err != nil {
+			return ids, fmt.Errorf("scene %s: starting %s: %w", scene.Name, anim.Animation, err)
+		}
+		ids = append(ids, params.Id)
+	}
+	return ids, nil
+}
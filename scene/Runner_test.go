@@ -0,0 +1,251 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package scene
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/alstest"
+	"github.com/AnimatedLEDStrip/client-go/fake"
+	"github.com/stretchr/testify/assert"
+)
+
+func testFile() *File {
+	return &File{
+		Scenes: []*Scene{
+			{
+				Name:       "warm",
+				Sections:   []*als.Section{als.NewSectionFromRange("left", 0, 4, "")},
+				Animations: []*als.AnimationToRunParams{als.NewAnimation("Color").Section("left").Color(0xFF8000).Build()},
+			},
+			{
+				Name: "party",
+				Animations: []*als.AnimationToRunParams{
+					als.NewAnimation("Meteor").Color(0xFF).Build(),
+					als.NewAnimation("Color").Section("left").Color(0xFF00).Build(),
+				},
+			},
+		},
+		Playlists: []*Playlist{
+			{Name: "evening", Entries: []*Entry{
+				{Scene: "warm", Duration: Duration(time.Hour)},
+				{Scene: "party", Duration: Duration(time.Hour), Transition: Replace, Fade: Duration(time.Hour)},
+				{Scene: "warm"},
+			}},
+			{Name: "quick", Repeat: 1, Entries: []*Entry{
+				{Scene: "warm", Duration: Duration(time.Millisecond)},
+				{Scene: "party", Duration: Duration(2 * time.Millisecond), Transition: Replace, Fade: Duration(time.Millisecond)},
+			}},
+		},
+	}
+}
+
+func newTestClient() *fake.Client {
+	return fake.NewClient(10, &als.AnimationInfo{Name: "Color"}, &als.AnimationInfo{Name: "Meteor"})
+}
+
+func runningAnimations(c als.Client) []string {
+	running, _ := c.GetRunningAnimations()
+	var names []string
+	for _, p := range running {
+		names = append(names, p.AnimationName+"@"+p.Section)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRunner_Controls(t *testing.T) {
+	client := newTestClient()
+	runner := NewRunner(client, testFile())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+	go func() { done <- runner.Run(ctx, "evening") }()
+
+	assert.Eventually(t, func() bool { return runner.Status().Scene == "warm" && len(runningAnimations(client)) == 1 },
+		time.Second, time.Millisecond)
+	assert.Equal(t, []string{"Color@left"}, runningAnimations(client))
+	sections, _ := client.GetSectionsMap()
+	assert.Contains(t, sections, "left")
+
+	runner.Pause()
+	assert.Eventually(t, func() bool { return runner.Status().Paused }, time.Second, time.Millisecond)
+	runner.Resume()
+	assert.Eventually(t, func() bool { return !runner.Status().Paused }, time.Second, time.Millisecond)
+
+	// Replace keeps the previous scene running during the fade
+	runner.Skip()
+	assert.Eventually(t, func() bool { return runner.Status().Entry == 1 && len(runningAnimations(client)) == 3 },
+		time.Second, time.Millisecond)
+	assert.Equal(t, []string{"Color@left", "Color@left", "Meteor@fullStrip"}, runningAnimations(client))
+
+	// Skipping during the fade moves to the next entry, whose cut ends both scenes
+	runner.Skip()
+	assert.Eventually(t, func() bool { return runner.Status().Entry == 2 && len(runningAnimations(client)) == 1 },
+		time.Second, time.Millisecond)
+	assert.Equal(t, []string{"Color@left"}, runningAnimations(client))
+
+	// The last entry has no duration, so it plays until skipped
+	runner.Skip()
+	assert.Nil(t, <-done)
+	assert.Len(t, runningAnimations(client), 0)
+	assert.Equal(t, Status{Entry: -1}, runner.Status())
+}
+
+func TestRunner_ReplaceWithoutFade(t *testing.T) {
+	file := testFile()
+	file.Playlists = []*Playlist{
+		{Name: "short", Entries: []*Entry{
+			{Scene: "warm", Duration: Duration(10 * time.Millisecond)},
+			{Scene: "party", Duration: Duration(10 * time.Millisecond), Transition: Replace},
+		}},
+		{Name: "long", Entries: []*Entry{
+			{Scene: "warm", Duration: Duration(time.Hour)},
+			{Scene: "party", Duration: Duration(time.Hour), Transition: Replace},
+		}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	client := newTestClient()
+	assert.Nil(t, NewRunner(client, file).Run(ctx, "short"))
+	assert.Len(t, runningAnimations(client), 0)
+
+	// The previous scene ends as soon as the next one has started
+	client = newTestClient()
+	runner := NewRunner(client, file)
+	done := make(chan error)
+	go func() { done <- runner.Run(ctx, "long") }()
+	assert.Eventually(t, func() bool { return len(runningAnimations(client)) == 1 }, time.Second, time.Millisecond)
+	runner.Skip()
+	assert.Eventually(t, func() bool { return runner.Status().Entry == 1 && len(runningAnimations(client)) == 2 },
+		time.Second, time.Millisecond)
+	assert.Equal(t, []string{"Color@left", "Meteor@fullStrip"}, runningAnimations(client))
+
+	runner.Skip()
+	assert.Nil(t, <-done)
+}
+
+func TestRunner_ReplaceKeepsReusedIds(t *testing.T) {
+	server := alstest.NewServer(alstest.WithNumLEDs(10), alstest.WithAnimations(&als.AnimationInfo{Name: "Color"}))
+	defer server.Close()
+	file := &File{
+		Scenes: []*Scene{{Name: "steady", Animations: []*als.AnimationToRunParams{
+			als.NewAnimation("Color").Id("x").Color(0xFF).Build(),
+		}}},
+		Playlists: []*Playlist{{Name: "loop", Repeat: -1, Entries: []*Entry{
+			{Scene: "steady", Duration: Duration(time.Hour), Transition: Replace},
+		}}},
+	}
+	starts := func() int {
+		var n int
+		for _, req := range server.Requests() {
+			if req == "POST /start" {
+				n++
+			}
+		}
+		return n
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runner := NewRunner(server.Client(), file)
+	done := make(chan error)
+	go func() { done <- runner.Run(ctx, "loop") }()
+	assert.Eventually(t, func() bool { return starts() == 1 }, time.Second, time.Millisecond)
+
+	// The second pass restarts x, which replaces it, so ending the first
+	// pass's animations must leave it running
+	runner.Skip()
+	assert.Eventually(t, func() bool { return starts() == 2 }, time.Second, time.Millisecond)
+	assert.Never(t, func() bool {
+		ids, _ := server.State.GetRunningAnimationsIds()
+		return len(ids) != 1 || ids[0] != "x"
+	}, 50*time.Millisecond, time.Millisecond)
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+	ids, _ := server.State.GetRunningAnimationsIds()
+	assert.Len(t, ids, 0)
+}
+
+func TestRunner_RepeatAgainstServer(t *testing.T) {
+	server := alstest.NewServer(alstest.WithNumLEDs(10), alstest.WithAnimations(&als.AnimationInfo{Name: "Color"}, &als.AnimationInfo{Name: "Meteor"}))
+	defer server.Close()
+
+	runner := NewRunner(server.Client(), testFile())
+	assert.Nil(t, runner.Run(context.Background(), "quick"))
+
+	var starts int
+	for _, req := range server.Requests() {
+		if req == "POST /start" {
+			starts++
+		}
+	}
+	// warm has one animation and party two, played twice
+	assert.Equal(t, 6, starts)
+	ids, _ := server.State.GetRunningAnimationsIds()
+	assert.Len(t, ids, 0)
+}
+
+func TestRunner_Cancel(t *testing.T) {
+	client := newTestClient()
+	runner := NewRunner(client, testFile())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+	go func() { done <- runner.Run(ctx, "evening") }()
+	assert.Eventually(t, func() bool { return len(runningAnimations(client)) == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Len(t, runningAnimations(client), 0)
+}
+
+func TestRunner_Errors(t *testing.T) {
+	runner := NewRunner(newTestClient(), testFile())
+	assert.NotNil(t, runner.Run(context.Background(), "missing"))
+
+	f := testFile()
+	f.Scenes[1].Animations = append(f.Scenes[1].Animations, als.NewAnimation("Ripple").Build())
+	client := newTestClient()
+	runner = NewRunner(client, f)
+	assert.NotNil(t, runner.Run(context.Background(), "quick"))
+	// Animations started before the failure are cleaned up
+	assert.Len(t, runningAnimations(client), 0)
+
+	// A file that wasn't validated by Parse fails instead of panicking
+	f = testFile()
+	f.Playlists[1].Entries[1].Scene = "missing"
+	client = newTestClient()
+	assert.NotNil(t, NewRunner(client, f).Run(context.Background(), "quick"))
+	assert.Len(t, runningAnimations(client), 0)
+	f.Playlists[1].Entries[1] = nil
+	assert.NotNil(t, NewRunner(client, f).Run(context.Background(), "quick"))
+	assert.Len(t, runningAnimations(client), 0)
+}
@@ -0,0 +1,215 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+// Package scene defines scene and playlist files and runs playlists
+// against a server
+package scene
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"gopkg.in/yaml.v3"
+)
+
+// Scene is a named set of animations, along with the sections they run on
+type Scene struct {
+	Name       string                      `json:"name"`
+	Sections   []*als.Section              `json:"sections"`
+	Animations []*als.AnimationToRunParams `json:"animations"`
+}
+
+// Transition is how a playlist moves from one scene to the next
+type Transition string
+
+const (
+	// Cut ends the previous scene's animations before starting the next scene
+	Cut Transition = "cut"
+	// Replace starts the next scene, then ends the previous scene's
+	// animations after the entry's Fade duration
+	Replace Transition = "replace"
+)
+
+// Entry is a scene played for a duration as part of a playlist
+type Entry struct {
+	Scene string `json:"scene"`
+	// Duration is how long the scene plays for; 0 plays it until skipped
+	Duration   Duration   `json:"duration"`
+	Transition Transition `json:"transition"`
+	// Fade is how long the previous scene keeps running alongside this one
+	// with the Replace transition
+	Fade Duration `json:"fade"`
+}
+
+// Playlist plays scenes in order
+type Playlist struct {
+	Name    string   `json:"name"`
+	Entries []*Entry `json:"entries"`
+	// Repeat is how many more times the playlist plays after the first;
+	// -1 repeats forever
+	Repeat int `json:"repeat"`
+}
+
+// File holds the scenes and playlists from a scene file
+type File struct {
+	Scenes    []*Scene    `json:"scenes"`
+	Playlists []*Playlist `json:"playlists"`
+}
+
+// Duration is a time.Duration that is written in files as a string such as
+// "1m30s", or as a number of seconds
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return nil
+}
+
+// Parse decodes a scene file. YAML files use the same field names as JSON.
+func Parse(data []byte, isYAML bool) (*File, error) {
+	if isYAML {
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	var f File
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, err
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Load reads a scene file, treating files ending in .yaml or .yml as YAML
+// and anything else as JSON
+func Load(path string) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	f, err := Parse(data, ext == ".yaml" || ext == ".yml")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Scene returns the named scene, or nil
+func (f *File) Scene(name string) *Scene {
+	for _, s := range f.Scenes {
+		if s != nil && s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Playlist returns the named playlist, or nil
+func (f *File) Playlist(name string) *Playlist {
+	for _, p := range f.Playlists {
+		if p != nil && p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func (f *File) validate() error {
+	names := map[string]bool{}
+	for i, s := range f.Scenes {
+		if s == nil {
+			return fmt.Errorf("scene %d is empty", i)
+		}
+		for j, sect := range s.Sections {
+			if sect == nil {
+				return fmt.Errorf("scene %s section %d is empty", s.Name, j)
+			}
+		}
+		for j, anim := range s.Animations {
+			if anim == nil {
+				return fmt.Errorf("scene %s animation %d is empty", s.Name, j)
+			}
+		}
+		if s.Name == "" {
+			return fmt.Errorf("scene without a name")
+		}
+		if names[s.Name] {
+			return fmt.Errorf("scene %s is defined more than once", s.Name)
+		}
+		names[s.Name] = true
+	}
+	for i, p := range f.Playlists {
+		if p == nil {
+			return fmt.Errorf("playlist %d is empty", i)
+		}
+		for i, e := range p.Entries {
+			if e == nil {
+				return fmt.Errorf("playlist %s entry %d is empty", p.Name, i)
+			}
+			if !names[e.Scene] {
+				return fmt.Errorf("playlist %s entry %d: unknown scene %q", p.Name, i, e.Scene)
+			}
+			switch e.Transition {
+			case "", Cut, Replace:
+			default:
+				return fmt.Errorf("playlist %s entry %d: unknown transition %q", p.Name, i, e.Transition)
+			}
+		}
+	}
+	return nil
+}
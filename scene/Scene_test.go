@@ -0,0 +1,134 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package scene
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const testYAML = `
+scenes:
+  - name: warm
+    sections:
+      - name: left
+        pixels: [0, 1, 2, 3, 4]
+        parentSectionName: fullStrip
+    animations:
+      - animation: Color
+        section: left
+        colors:
+          - type: ColorContainer
+            colors: [0xFF8000]
+  - name: party
+    animations:
+      - animation: Meteor
+        runCount: -1
+        intParams:
+          length: 5
+playlists:
+  - name: evening
+    repeat: -1
+    entries:
+      - scene: warm
+        duration: 30m
+      - scene: party
+        duration: 90
+        transition: replace
+        fade: 5s
+`
+
+func TestParse_YAML(t *testing.T) {
+	f, err := Parse([]byte(testYAML), true)
+	assert.Nil(t, err)
+
+	warm := f.Scene("warm")
+	assert.NotNil(t, warm)
+	assert.Equal(t, als.NewSectionFromRange("left", 0, 4, "fullStrip"), warm.Sections[0])
+	assert.Equal(t, []int{0xFF8000}, warm.Animations[0].Colors[0].Colors)
+	assert.Equal(t, 5, f.Scene("party").Animations[0].IntParams["length"])
+
+	evening := f.Playlist("evening")
+	assert.Equal(t, -1, evening.Repeat)
+	assert.Equal(t, Duration(30*time.Minute), evening.Entries[0].Duration)
+	assert.Equal(t, Duration(90*time.Second), evening.Entries[1].Duration)
+	assert.Equal(t, Replace, evening.Entries[1].Transition)
+	assert.Equal(t, Duration(5*time.Second), evening.Entries[1].Fade)
+	assert.Nil(t, f.Playlist("missing"))
+}
+
+func TestParse_JSON(t *testing.T) {
+	f, err := Parse([]byte(`{"scenes":[{"name":"a","animations":[{"animation":"Color"}]}],
+		"playlists":[{"name":"p","entries":[{"scene":"a","duration":"1.5s"}]}]}`), false)
+	assert.Nil(t, err)
+	assert.Equal(t, Duration(1500*time.Millisecond), f.Playlists[0].Entries[0].Duration)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	invalid := []string{
+		`{"scenes":[{"name":"a"},{"name":"a"}]}`,
+		`{"scenes":[{"animations":[]}]}`,
+		`{"playlists":[{"name":"p","entries":[{"scene":"missing"}]}]}`,
+		`{"scenes":[{"name":"a"}],"playlists":[{"name":"p","entries":[{"scene":"a","transition":"dissolve"}]}]}`,
+		`{"scenes":[{"name":"a"}],"playlists":[{"name":"p","entries":[{"scene":"a","duration":"soon"}]}]}`,
+		`{"scene":[]}`,
+		`{"scenes":[null]}`,
+		`{"scenes":[{"name":"a","animations":[null]}]}`,
+		`{"scenes":[{"name":"a","sections":[null]}]}`,
+		`{"playlists":[null]}`,
+		`{"scenes":[{"name":"a"}],"playlists":[{"name":"p","entries":[null]}]}`,
+	}
+	for _, data := range invalid {
+		_, err := Parse([]byte(data), false)
+		assert.NotNil(t, err, data)
+	}
+	_, err := Parse([]byte("scenes: [unclosed"), true)
+	assert.NotNil(t, err)
+	_, err = Parse([]byte("scenes: [null]"), true)
+	assert.NotNil(t, err)
+	_, err = Parse([]byte("scenes: [{name: a}]\nplaylists: [{name: p, entries: [null]}]"), true)
+	assert.NotNil(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scene")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "scenes.yml")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(testYAML), 0644))
+	f, err := Load(path)
+	assert.Nil(t, err)
+	assert.Len(t, f.Scenes, 2)
+
+	path = filepath.Join(dir, "scenes.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(testYAML), 0644))
+	_, err = Load(path)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,257 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+// Package schedule runs client actions on cron expressions and at one-shot
+// times, in any time zone, with the schedule persisted to disk.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchDays bounds the search for a cron expression's next time, so
+// expressions that can never match (such as "0 0 30 2 *") end the search
+const maxSearchDays = 366 * 5
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12,
+		names: []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	dowField = cronField{name: "day of week", min: 0, max: 7,
+		names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Cron is a parsed five-field cron expression:
+// minute, hour, day of month, month and day of week.
+//
+// Fields accept *, numbers, ranges (1-5), steps (*/15, 0-30/10) and lists
+// (1,15), and month and day of week accept three-letter names (jan, mon).
+// Day of week 0 and 7 are both Sunday. As in cron, when both day of month
+// and day of week are restricted, a day matching either one matches.
+// The macros @yearly, @monthly, @weekly, @daily and @hourly are also accepted.
+type Cron struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// ParseCron parses a cron expression
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "@") {
+		macro, ok := cronMacros[strings.ToLower(fields[0])]
+		if !ok {
+			return nil, fmt.Errorf("cron %q: unknown macro", expr)
+		}
+		fields = strings.Fields(macro)
+	}
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	c := &Cron{expr: strings.Join(strings.Fields(expr), " ")}
+	var err error
+	if c.minute, err = parseCronField(fields[0], minuteField); err != nil {
+		return nil, fmt.Errorf("cron %q: %w", expr, err)
+	}
+	if c.hour, err = parseCronField(fields[1], hourField); err != nil {
+		return nil, fmt.Errorf("cron %q: %w", expr, err)
+	}
+	if c.dom, err = parseCronField(fields[2], domField); err != nil {
+		return nil, fmt.Errorf("cron %q: %w", expr, err)
+	}
+	if c.month, err = parseCronField(fields[3], monthField); err != nil {
+		return nil, fmt.Errorf("cron %q: %w", expr, err)
+	}
+	if c.dow, err = parseCronField(fields[4], dowField); err != nil {
+		return nil, fmt.Errorf("cron %q: %w", expr, err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// parseCronField parses one field into a bit set of its allowed values
+func parseCronField(s string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step in %q", field.name, part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		var low, high int
+		switch {
+		case rangePart == "*":
+			low, high = field.min, field.max
+			if field.max == 7 {
+				high = 6
+			}
+		case strings.Contains(rangePart, "-"):
+			i := strings.Index(rangePart, "-")
+			var err error
+			if low, err = field.value(rangePart[:i]); err != nil {
+				return 0, err
+			}
+			if high, err = field.value(rangePart[i+1:]); err != nil {
+				return 0, err
+			}
+			if high < low {
+				return 0, fmt.Errorf("%s: invalid range %q", field.name, rangePart)
+			}
+		default:
+			var err error
+			if low, err = field.value(rangePart); err != nil {
+				return 0, err
+			}
+			high = low
+			if step > 1 {
+				// "5/15" means every 15 starting at 5
+				high = field.max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: invalid value %q", f.name, s)
+	}
+	return v, nil
+}
+
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Next returns the first time after the given time that matches the
+// expression, evaluated on the wall clock of after's location. A time
+// skipped when clocks go forward runs at the equivalent instant just after
+// the jump, and a time repeated when clocks go back only matches its first
+// occurrence. Next returns the zero time if nothing matches in the next
+// five years.
+func (c *Cron) Next(after time.Time) time.Time {
+	loc := after.Location()
+	year, month, day := after.Date()
+	for i := 0; i < maxSearchDays; i++ {
+		y, m, d := time.Date(year, month, day+i, 12, 0, 0, 0, loc).Date()
+		if !c.matchesDay(y, m, d, loc) {
+			continue
+		}
+
+		// Search the whole day, since a time in a DST gap can be moved past
+		// times that come after it on the wall clock
+		var best time.Time
+		for h := 0; h < 24; h++ {
+			if c.hour&(1<<uint(h)) == 0 {
+				continue
+			}
+			for min := 0; min < 60; min++ {
+				if c.minute&(1<<uint(min)) == 0 {
+					continue
+				}
+				t := wallTime(y, m, d, h, min, loc)
+				if t.After(after) && (best.IsZero() || t.Before(best)) {
+					best = t
+				}
+			}
+		}
+		if !best.IsZero() {
+			return best
+		}
+	}
+	return time.Time{}
+}
+
+func (c *Cron) matchesDay(y int, m time.Month, d int, loc *time.Location) bool {
+	if c.month&(1<<uint(m)) == 0 {
+		return false
+	}
+	domMatch := c.dom&(1<<uint(d)) != 0
+	dowMatch := c.dow&(1<<uint(time.Date(y, m, d, 12, 0, 0, 0, loc).Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// wallTime returns the earliest instant showing the given wall clock time
+// in loc or, if it falls in a DST gap, the instant just after the gap that
+// is the same time from the start of the day
+func wallTime(y int, m time.Month, d, h, min int, loc *time.Location) time.Time {
+	t := time.Date(y, m, d, h, min, 0, 0, loc)
+	utc := time.Date(y, m, d, h, min, 0, 0, time.UTC)
+	gap := t.Hour() != h || t.Minute() != min
+	// Check the offsets in effect around t for other instants with the same
+	// wall clock time, or for a gap, the instant using the offset before it
+	for _, around := range []time.Time{t.Add(-24 * time.Hour), t.Add(24 * time.Hour)} {
+		_, offset := around.Zone()
+		u := utc.Add(-time.Duration(offset) * time.Second).In(loc)
+		if gap {
+			if u.After(t) {
+				t = u
+			}
+		} else if u.Before(t) && u.Hour() == h && u.Minute() == min && u.Day() == d {
+			t = u
+		}
+	}
+	return t
+}
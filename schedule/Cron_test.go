@@ -0,0 +1,118 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestParseCron(t *testing.T) {
+	c, err := ParseCron("*/15 9-17 * jan-mar,dec MON-fri")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1|1<<15|1<<30|1<<45), c.minute)
+	assert.Equal(t, uint64(0x3FE00), c.hour)
+	assert.Equal(t, uint64(1<<1|1<<2|1<<3|1<<12), c.month)
+	assert.Equal(t, uint64(0x3E), c.dow)
+	assert.Equal(t, "*/15 9-17 * jan-mar,dec MON-fri", c.String())
+
+	c, _ = ParseCron("5/20 0 1 * 7")
+	assert.Equal(t, uint64(1<<5|1<<25|1<<45), c.minute)
+	assert.Equal(t, uint64(1|1<<7), c.dow)
+
+	c, _ = ParseCron("@daily")
+	assert.Equal(t, uint64(1), c.minute)
+	assert.Equal(t, uint64(1), c.hour)
+}
+
+func TestParseCron_Errors(t *testing.T) {
+	for _, expr := range []string{
+		"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *", "@often",
+	} {
+		_, err := ParseCron(expr)
+		assert.NotNil(t, err, expr)
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	weekdays, _ := ParseCron("0 18 * * 1-5")
+
+	// Friday evening runs, then nothing until Monday
+	friday := time.Date(2021, 3, 5, 17, 59, 0, 0, ny)
+	assert.Equal(t, time.Date(2021, 3, 5, 18, 0, 0, 0, ny), weekdays.Next(friday))
+	assert.Equal(t, time.Date(2021, 3, 8, 18, 0, 0, 0, ny), weekdays.Next(time.Date(2021, 3, 5, 18, 0, 0, 0, ny)))
+
+	// Evaluated on the wall clock of the time's location
+	assert.Equal(t, time.Date(2021, 3, 8, 18, 0, 0, 0, time.UTC), weekdays.Next(friday.UTC()))
+	assert.Equal(t, time.Date(2021, 3, 5, 18, 0, 0, 0, time.UTC), weekdays.Next(time.Date(2021, 3, 5, 12, 0, 0, 0, time.UTC)))
+
+	// Day of month or day of week, when both are restricted
+	either, _ := ParseCron("0 0 13 * fri")
+	assert.Equal(t, time.Date(2021, 8, 6, 0, 0, 0, 0, time.UTC), either.Next(time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2021, 8, 13, 0, 0, 0, 0, time.UTC), either.Next(time.Date(2021, 8, 6, 0, 0, 0, 0, time.UTC)))
+
+	never, _ := ParseCron("0 0 30 2 *")
+	assert.True(t, never.Next(friday).IsZero())
+}
+
+func TestCron_NextSpringForward(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	c, _ := ParseCron("30 2 * * *")
+
+	// 02:30 doesn't exist on 2021-03-14, so it runs just after the jump
+	next := c.Next(time.Date(2021, 3, 14, 0, 0, 0, 0, ny))
+	assert.Equal(t, time.Date(2021, 3, 14, 7, 30, 0, 0, time.UTC), next.UTC())
+	assert.Equal(t, time.Date(2021, 3, 15, 2, 30, 0, 0, ny), c.Next(next))
+
+	// A time moved by the gap doesn't run after a later time on the same day
+	c, _ = ParseCron("0,30 2,3 * * *")
+	assert.Equal(t, time.Date(2021, 3, 14, 7, 0, 0, 0, time.UTC), c.Next(time.Date(2021, 3, 14, 0, 0, 0, 0, ny)).UTC())
+}
+
+func TestCron_NextFallBack(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	c, _ := ParseCron("30 1 * * *")
+
+	// 01:30 happens twice on 2021-11-07 but only runs the first time
+	next := c.Next(time.Date(2021, 11, 7, 0, 0, 0, 0, ny))
+	assert.Equal(t, time.Date(2021, 11, 7, 5, 30, 0, 0, time.UTC), next.UTC())
+	next = c.Next(next)
+	assert.Equal(t, time.Date(2021, 11, 8, 6, 30, 0, 0, time.UTC), next.UTC())
+
+	hourly, _ := ParseCron("@hourly")
+	next = hourly.Next(time.Date(2021, 11, 7, 0, 30, 0, 0, ny))
+	assert.Equal(t, time.Date(2021, 11, 7, 5, 0, 0, 0, time.UTC), next.UTC())
+	assert.Equal(t, time.Date(2021, 11, 7, 7, 0, 0, 0, time.UTC), hourly.Next(next).UTC())
+}
@@ -0,0 +1,179 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+)
+
+// ActionType identifies what an Action does
+type ActionType string
+
+const (
+	// StartAnimationAction starts Action.Animation
+	StartAnimationAction ActionType = "startAnimation"
+	// EndAnimationAction ends the running animation with Action.Id
+	EndAnimationAction ActionType = "endAnimation"
+	// ClearStripAction clears the strip
+	ClearStripAction ActionType = "clearStrip"
+	// StartSceneAction starts the scene named Action.Scene
+	StartSceneAction ActionType = "startScene"
+)
+
+// Action is what an Entry does when it runs
+type Action struct {
+	Type      ActionType                `json:"type"`
+	Animation *als.AnimationToRunParams `json:"animation,omitempty"`
+	Id        string                    `json:"id,omitempty"`
+	Scene     string                    `json:"scene,omitempty"`
+}
+
+func (a *Action) String() string {
+	switch a.Type {
+	case StartAnimationAction:
+		if a.Animation != nil && a.Animation.Id != "" {
+			return fmt.Sprintf("start %s (%s)", a.Animation.Animation, a.Animation.Id)
+		}
+		if a.Animation != nil {
+			return "start " + a.Animation.Animation
+		}
+	case EndAnimationAction:
+		return "end " + a.Id
+	case ClearStripAction:
+		return "clear strip"
+	case StartSceneAction:
+		return "start scene " + a.Scene
+	}
+	return string(a.Type)
+}
+
+// Entry runs an Action, either repeatedly on a cron expression or once at a
+// fixed time
+type Entry struct {
+	Name string `json:"name"`
+	// Cron is a cron expression, see Cron
+	Cron string `json:"cron,omitempty"`
+	// At is the time a one-shot entry runs
+	At *time.Time `json:"at,omitempty"`
+	// TimeZone is the IANA time zone Cron is evaluated in; if empty, the
+	// scheduler's location is used
+	TimeZone string `json:"timeZone,omitempty"`
+	Action   Action `json:"action"`
+}
+
+// Schedule is the set of entries a Scheduler persists
+type Schedule struct {
+	Entries []*Entry `json:"entries"`
+}
+
+// validate checks the entry, returning its parsed cron expression and
+// location for cron entries
+func (e *Entry) validate() (*Cron, *time.Location, error) {
+	if e.Name == "" {
+		return nil, nil, errors.New("entry has no name")
+	}
+	switch e.Action.Type {
+	case StartAnimationAction:
+		if e.Action.Animation == nil || e.Action.Animation.Animation == "" {
+			return nil, nil, fmt.Errorf("entry %s: no animation to start", e.Name)
+		}
+	case EndAnimationAction:
+		if e.Action.Id == "" {
+			return nil, nil, fmt.Errorf("entry %s: no animation id to end", e.Name)
+		}
+	case ClearStripAction:
+	case StartSceneAction:
+		if e.Action.Scene == "" {
+			return nil, nil, fmt.Errorf("entry %s: no scene to start", e.Name)
+		}
+	default:
+		return nil, nil, fmt.Errorf("entry %s: unknown action type %q", e.Name, e.Action.Type)
+	}
+
+	if (e.Cron == "") == (e.At == nil) {
+		return nil, nil, fmt.Errorf("entry %s: exactly one of cron and at must be set", e.Name)
+	}
+	var loc *time.Location
+	if e.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(e.TimeZone); err != nil {
+			return nil, nil, fmt.Errorf("entry %s: %w", e.Name, err)
+		}
+	}
+	if e.At != nil {
+		return nil, loc, nil
+	}
+	cron, err := ParseCron(e.Cron)
+	if err != nil {
+		return nil, nil, fmt.Errorf("entry %s: %w", e.Name, err)
+	}
+	return cron, loc, nil
+}
+
+// LoadSchedule reads a schedule from a JSON file
+func LoadSchedule(path string) (*Schedule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Schedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// Save writes the schedule to a JSON file, replacing it atomically so a
+// crash never leaves a partial schedule behind
+func (s *Schedule) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
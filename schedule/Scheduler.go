@@ -0,0 +1,356 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/internal/clock"
+	"github.com/AnimatedLEDStrip/client-go/scene"
+)
+
+// Clock provides the time to a Scheduler, so tests can control it
+type Clock = clock.Clock
+
+// SchedulerOption configures a Scheduler
+type SchedulerOption func(*Scheduler)
+
+// WithClock sets the clock entries are scheduled with
+func WithClock(clock Clock) SchedulerOption {
+	return func(s *Scheduler) {
+		s.clock = clock
+	}
+}
+
+// WithLocation sets the time zone cron entries without their own TimeZone
+// are evaluated in. The default is the local time zone.
+func WithLocation(loc *time.Location) SchedulerOption {
+	return func(s *Scheduler) {
+		s.location = loc
+	}
+}
+
+// WithStore persists the schedule to a JSON file, loading it when the
+// scheduler is created and saving it whenever it changes
+func WithStore(path string) SchedulerOption {
+	return func(s *Scheduler) {
+		s.path = path
+	}
+}
+
+// WithScenes sets the scene file that StartSceneAction entries refer to
+func WithScenes(file *scene.File) SchedulerOption {
+	return func(s *Scheduler) {
+		s.scenes = file
+	}
+}
+
+// WithLogger sets the logger that runs and failed actions are reported to
+func WithLogger(logger als.Logger) SchedulerOption {
+	return func(s *Scheduler) {
+		s.logger = logger
+	}
+}
+
+type scheduled struct {
+	entry *Entry
+	cron  *Cron
+	loc   *time.Location
+	next  time.Time
+}
+
+// Scheduler runs the actions of its entries against a server when they are
+// due. Entries can be added and removed from any goroutine while Run is
+// running.
+type Scheduler struct {
+	client   als.Client
+	clock    Clock
+	location *time.Location
+	path     string
+	scenes   *scene.File
+	logger   als.Logger
+	changed  chan struct{}
+
+	mu      sync.Mutex
+	entries []*scheduled
+}
+
+// NewScheduler creates a scheduler, loading its entries from the store if
+// one is set and exists
+func NewScheduler(client als.Client, opts ...SchedulerOption) (*Scheduler, error) {
+	s := &Scheduler{
+		client:   client,
+		clock:    clock.Real{},
+		location: time.Local,
+		changed:  make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.path != "" {
+		stored, err := LoadSchedule(s.path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if stored != nil {
+			for _, entry := range stored.Entries {
+				if err := s.add(entry); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+// Add adds an entry, replacing any entry with the same name. A one-shot
+// entry whose time has already passed runs as soon as possible.
+func (s *Scheduler) Add(entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.add(entry); err != nil {
+		return err
+	}
+	s.notify()
+	return s.save()
+}
+
+func (s *Scheduler) add(entry *Entry) error {
+	cron, loc, err := entry.validate()
+	if err != nil {
+		return err
+	}
+	if entry.Action.Type == StartSceneAction && (s.scenes == nil || s.scenes.Scene(entry.Action.Scene) == nil) {
+		return fmt.Errorf("entry %s: unknown scene %q", entry.Name, entry.Action.Scene)
+	}
+	if loc == nil {
+		loc = s.location
+	}
+
+	e := *entry
+	item := &scheduled{entry: &e, cron: cron, loc: loc}
+	if e.At != nil {
+		item.next = *e.At
+	} else {
+		item.next = cron.Next(s.clock.Now().In(loc))
+	}
+
+	for i, existing := range s.entries {
+		if existing.entry.Name == e.Name {
+			s.entries[i] = item
+			return nil
+		}
+	}
+	s.entries = append(s.entries, item)
+	return nil
+}
+
+// Remove removes the entry with the given name
+func (s *Scheduler) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, item := range s.entries {
+		if item.entry.Name == name {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			s.notify()
+			return s.save()
+		}
+	}
+	return fmt.Errorf("entry %s: %w", name, als.ErrNotFound)
+}
+
+// Entries returns copies of the scheduler's entries
+func (s *Scheduler) Entries() []*Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]*Entry, len(s.entries))
+	for i, item := range s.entries {
+		e := *item.entry
+		entries[i] = &e
+	}
+	return entries
+}
+
+// NextRun returns when the named entry will next run. It returns false if
+// there is no such entry or it will never run again.
+func (s *Scheduler) NextRun(name string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range s.entries {
+		if item.entry.Name == name {
+			return item.next, !item.next.IsZero()
+		}
+	}
+	return time.Time{}, false
+}
+
+// Run runs entries as they come due until ctx is done. Runs missed while
+// the scheduler wasn't running are not made up, except for one-shot
+// entries, which are removed once they have run. Failed actions are logged
+// and don't stop the scheduler.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.skipMissed(s.clock.Now())
+	for {
+		now := s.clock.Now()
+		due, wake := s.takeDue(now)
+		for _, entry := range due {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.run(ctx, entry)
+		}
+		if len(due) > 0 {
+			continue
+		}
+
+		var timer <-chan time.Time
+		if !wake.IsZero() {
+			timer = s.clock.After(wake.Sub(now))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer:
+		case <-s.changed:
+		}
+	}
+}
+
+// skipMissed moves cron entries that came due before now to their next run
+func (s *Scheduler) skipMissed(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range s.entries {
+		if item.cron != nil && item.next.Before(now) {
+			item.next = item.cron.Next(now.In(item.loc))
+		}
+	}
+}
+
+// takeDue returns the entries due at now, in the order they came due,
+// advancing them to their next run, and the time the next entry comes due
+func (s *Scheduler) takeDue(now time.Time) ([]*Entry, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*scheduled
+	remaining := s.entries[:0]
+	for _, item := range s.entries {
+		if !item.next.IsZero() && !item.next.After(now) {
+			due = append(due, item)
+			if item.cron == nil {
+				continue
+			}
+		}
+		remaining = append(remaining, item)
+	}
+	s.entries = remaining
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].next.Before(due[j].next)
+	})
+
+	entries := make([]*Entry, len(due))
+	for i, item := range due {
+		entries[i] = item.entry
+		if item.cron != nil {
+			item.next = item.cron.Next(now.In(item.loc))
+		}
+	}
+	if len(due) > 0 {
+		if err := s.save(); err != nil {
+			s.logError("saving schedule failed", "error", err)
+		}
+	}
+
+	var wake time.Time
+	for _, item := range s.entries {
+		if !item.next.IsZero() && (wake.IsZero() || item.next.Before(wake)) {
+			wake = item.next
+		}
+	}
+	return entries, wake
+}
+
+func (s *Scheduler) run(ctx context.Context, entry *Entry) {
+	if s.logger != nil {
+		s.logger.Debug("running scheduled action", "entry", entry.Name, "action", entry.Action.String())
+	}
+	if err := s.execute(ctx, &entry.Action); err != nil && ctx.Err() == nil {
+		s.logError("scheduled action failed", "entry", entry.Name, "action", entry.Action.String(), "error", err)
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, action *Action) error {
+	switch action.Type {
+	case StartAnimationAction:
+		params := *action.Animation
+		_, err := s.client.StartAnimationContext(ctx, &params)
+		return err
+	case EndAnimationAction:
+		_, err := s.client.EndAnimationContext(ctx, action.Id)
+		return err
+	case ClearStripAction:
+		return s.client.ClearStripContext(ctx)
+	case StartSceneAction:
+		sc := s.scenes.Scene(action.Scene)
+		if sc == nil {
+			return fmt.Errorf("unknown scene %q", action.Scene)
+		}
+		_, err := scene.StartScene(ctx, s.client, sc)
+		return err
+	}
+	return errors.New("unknown action type " + string(action.Type))
+}
+
+// save persists the entries to the store, if one is set; s.mu must be held
+func (s *Scheduler) save() error {
+	if s.path == "" {
+		return nil
+	}
+	stored := &Schedule{Entries: make([]*Entry, len(s.entries))}
+	for i, item := range s.entries {
+		stored.Entries[i] = item.entry
+	}
+	return stored.Save(s.path)
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) logError(msg string, args ...interface{}) {
+	if s.logger != nil {
+		s.logger.Error(msg, args...)
+	}
+}
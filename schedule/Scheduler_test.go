@@ -0,0 +1,219 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/AnimatedLEDStrip/client-go/fake"
+	"github.com/AnimatedLEDStrip/client-go/internal/clock"
+	"github.com/AnimatedLEDStrip/client-go/scene"
+	"github.com/stretchr/testify/assert"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "schedule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestScheduler_Run(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	path := filepath.Join(tempDir(t), "schedule.json")
+	stored := &Schedule{Entries: []*Entry{
+		{Name: "evening", Cron: "0 18 * * mon-fri", Action: Action{
+			Type: StartAnimationAction, Animation: &als.AnimationToRunParams{Animation: "Rainbow", Id: "lobby"}}},
+		{Name: "night", Cron: "0 23 * * mon-fri", Action: Action{Type: ClearStripAction}},
+		{Name: "party", At: timePtr(time.Date(2021, 3, 12, 20, 0, 0, 0, ny)), Action: Action{Type: EndAnimationAction, Id: "lobby"}},
+	}}
+	assert.Nil(t, stored.Save(path))
+
+	client := fake.NewClient(3, &als.AnimationInfo{Name: "Rainbow", RunCountDefault: -1})
+	// Friday, two days before clocks go forward
+	clk := clock.NewFake(time.Date(2021, 3, 12, 17, 59, 0, 0, ny))
+	s, err := NewScheduler(client, WithClock(clk), WithLocation(ny), WithStore(path))
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	<-clk.Waiting()
+	clk.Advance(time.Minute)
+	<-clk.Waiting()
+	ids, _ := client.GetRunningAnimationsIds()
+	assert.Equal(t, []string{"lobby"}, ids)
+	next, ok := s.NextRun("evening")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2021, 3, 15, 22, 0, 0, 0, time.UTC), next.UTC())
+
+	// The one-shot entry runs once and is removed from the store
+	clk.Advance(2 * time.Hour)
+	<-clk.Waiting()
+	ids, _ = client.GetRunningAnimationsIds()
+	assert.Empty(t, ids)
+	_, ok = s.NextRun("party")
+	assert.False(t, ok)
+	reloaded, err := LoadSchedule(path)
+	assert.Nil(t, err)
+	assert.Len(t, reloaded.Entries, 2)
+
+	client.SetStripColor([]int{1, 2, 3})
+	clk.Advance(3 * time.Hour)
+	<-clk.Waiting()
+	color, _ := client.GetCurrentStripColor()
+	assert.Equal(t, []int{0, 0, 0}, color)
+	next, _ = s.NextRun("night")
+	assert.Equal(t, time.Date(2021, 3, 16, 3, 0, 0, 0, time.UTC), next.UTC())
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+func TestScheduler_SkipsMissedRuns(t *testing.T) {
+	client := fake.NewClient(3)
+	clk := clock.NewFake(time.Date(2021, 3, 12, 17, 0, 0, 0, time.UTC))
+	s, _ := NewScheduler(client, WithClock(clk), WithLocation(time.UTC))
+	assert.Nil(t, s.Add(&Entry{Name: "hourly", Cron: "@hourly", Action: Action{Type: ClearStripAction}}))
+	client.SetStripColor([]int{1, 2, 3})
+	clk.Advance(90 * time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+	<-clk.Waiting()
+	color, _ := client.GetCurrentStripColor()
+	assert.Equal(t, []int{1, 2, 3}, color)
+	next, _ := s.NextRun("hourly")
+	assert.Equal(t, time.Date(2021, 3, 12, 19, 0, 0, 0, time.UTC), next)
+
+	cancel()
+	<-done
+}
+
+func TestScheduler_AddWhileRunning(t *testing.T) {
+	client := fake.NewClient(3, &als.AnimationInfo{Name: "Color"})
+	file := &scene.File{Scenes: []*scene.Scene{{
+		Name:       "evening",
+		Sections:   []*als.Section{als.NewSection("left", []int{0, 1}, "")},
+		Animations: []*als.AnimationToRunParams{{Animation: "Color", Id: "warm", Section: "left"}},
+	}}}
+	clk := clock.NewFake(time.Date(2021, 3, 12, 17, 0, 0, 0, time.UTC))
+	s, _ := NewScheduler(client, WithClock(clk), WithScenes(file))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	// A one-shot time that has passed runs straight away
+	assert.Nil(t, s.Add(&Entry{Name: "now", At: timePtr(clk.Now().Add(-time.Minute)),
+		Action: Action{Type: StartSceneAction, Scene: "evening"}}))
+	assert.Eventually(t, func() bool {
+		ids, _ := client.GetRunningAnimationsIds()
+		return len(ids) == 1 && ids[0] == "warm"
+	}, time.Second, time.Millisecond)
+	_, err := client.GetSection("left")
+	assert.Nil(t, err)
+	assert.Empty(t, s.Entries())
+
+	cancel()
+	<-done
+}
+
+func TestScheduler_Entries(t *testing.T) {
+	path := filepath.Join(tempDir(t), "schedule.json")
+	clk := clock.NewFake(time.Date(2021, 3, 12, 12, 0, 0, 0, time.UTC))
+	s, err := NewScheduler(fake.NewClient(3), WithClock(clk), WithLocation(time.UTC), WithStore(path))
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Add(&Entry{Name: "london", Cron: "0 18 * * *", TimeZone: "Europe/London",
+		Action: Action{Type: ClearStripAction}}))
+	assert.Nil(t, s.Add(&Entry{Name: "end", Cron: "0 23 * * *", Action: Action{Type: EndAnimationAction, Id: "a"}}))
+	assert.Nil(t, s.Add(&Entry{Name: "end", Cron: "0 22 * * *", Action: Action{Type: EndAnimationAction, Id: "a"}}))
+
+	next, _ := s.NextRun("london")
+	assert.Equal(t, time.Date(2021, 3, 12, 18, 0, 0, 0, time.UTC), next.UTC())
+	next, _ = s.NextRun("end")
+	assert.Equal(t, time.Date(2021, 3, 12, 22, 0, 0, 0, time.UTC), next)
+
+	entries := s.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "0 22 * * *", entries[1].Cron)
+
+	// The schedule is loaded again from the store
+	again, err := NewScheduler(fake.NewClient(3), WithClock(clk), WithStore(path))
+	assert.Nil(t, err)
+	assert.Equal(t, entries, again.Entries())
+
+	assert.Nil(t, s.Remove("london"))
+	assert.True(t, errors.Is(s.Remove("london"), als.ErrNotFound))
+	stored, _ := LoadSchedule(path)
+	assert.Len(t, stored.Entries, 1)
+}
+
+func TestScheduler_InvalidEntries(t *testing.T) {
+	s, _ := NewScheduler(fake.NewClient(3))
+	at := timePtr(time.Now())
+	for _, entry := range []*Entry{
+		{Cron: "@daily", Action: Action{Type: ClearStripAction}},
+		{Name: "neither", Action: Action{Type: ClearStripAction}},
+		{Name: "both", Cron: "@daily", At: at, Action: Action{Type: ClearStripAction}},
+		{Name: "cron", Cron: "every day", Action: Action{Type: ClearStripAction}},
+		{Name: "zone", Cron: "@daily", TimeZone: "Mars/Olympus", Action: Action{Type: ClearStripAction}},
+		{Name: "type", At: at, Action: Action{Type: "dance"}},
+		{Name: "start", At: at, Action: Action{Type: StartAnimationAction}},
+		{Name: "end", At: at, Action: Action{Type: EndAnimationAction}},
+		{Name: "scene", At: at, Action: Action{Type: StartSceneAction, Scene: "evening"}},
+	} {
+		assert.NotNil(t, s.Add(entry), entry.Name)
+	}
+	assert.Empty(t, s.Entries())
+}
+
+func TestAction_String(t *testing.T) {
+	assert.Equal(t, "start Rainbow (lobby)",
+		(&Action{Type: StartAnimationAction, Animation: &als.AnimationToRunParams{Animation: "Rainbow", Id: "lobby"}}).String())
+	assert.Equal(t, "end lobby", (&Action{Type: EndAnimationAction, Id: "lobby"}).String())
+	assert.Equal(t, "clear strip", (&Action{Type: ClearStripAction}).String())
+	assert.Equal(t, "start scene evening", (&Action{Type: StartSceneAction, Scene: "evening"}).String())
+}
@@ -0,0 +1,215 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package simulator
+
+import (
+	als "github.com/AnimatedLEDStrip/client-go"
+)
+
+// step is a frame along with the number of ticks it is shown for
+type step struct {
+	colors []int
+	hold   int
+}
+
+// animation produces the steps of one run of an animation
+type animation struct {
+	info *als.AnimationInfo
+	run  func(s *Simulator) []step
+}
+
+var oneDimensional = []string{"ONE_DIMENSIONAL"}
+
+var directionParam = &als.AnimationParameter{
+	Name:        "direction",
+	Description: "forward or backward",
+	Default:     "forward",
+	Kind:        als.StringParameter,
+}
+
+var animations = []*animation{
+	{
+		info: &als.AnimationInfo{
+			Name: "Alternate", Abbr: "ALT", Description: "Alternates between the colors, showing each for delay ms",
+			RunCountDefault: -1, MinimumColors: 2, UnlimitedColors: true, Dimensionality: oneDimensional,
+			IntParams: []*als.AnimationParameter{{Name: "delay", Default: 1000, Kind: als.IntParameter}},
+		},
+		run: func(s *Simulator) []step {
+			steps := make([]step, 0, len(s.colors))
+			for _, c := range s.colors {
+				steps = append(steps, step{colors: c, hold: s.ticks("delay", 1000)})
+			}
+			return steps
+		},
+	},
+	{
+		info: &als.AnimationInfo{
+			Name: "Bounce", Abbr: "BNC", Description: "A pixel bounces between the ends, which fill in as it reaches them",
+			RunCountDefault: -1, MinimumColors: 1, Dimensionality: oneDimensional,
+		},
+		run: func(s *Simulator) []step {
+			lit := make([]int, s.numLEDs)
+			var steps []step
+			show := func(pos int) {
+				frame := append([]int(nil), lit...)
+				frame[pos] = s.colors[0][pos]
+				steps = append(steps, step{colors: frame, hold: 1})
+			}
+			for lo, hi := 0, s.numLEDs-1; lo <= hi; {
+				for pos := lo; pos <= hi; pos++ {
+					show(pos)
+				}
+				lit[hi] = s.colors[0][hi]
+				hi--
+				for pos := hi; pos >= lo; pos-- {
+					show(pos)
+				}
+				if lo <= hi {
+					lit[lo] = s.colors[0][lo]
+				}
+				lo++
+			}
+			return steps
+		},
+	},
+	{
+		info: &als.AnimationInfo{
+			Name: "Color", Abbr: "COL", Description: "Sets the section to a color",
+			RunCountDefault: 1, MinimumColors: 1, Dimensionality: oneDimensional,
+		},
+		run: func(s *Simulator) []step {
+			return []step{{colors: s.colors[0], hold: 1}}
+		},
+	},
+	{
+		info: &als.AnimationInfo{
+			Name: "Meteor", Abbr: "MET", Description: "A meteor with a fading tail moves along the section",
+			RunCountDefault: -1, MinimumColors: 1, Dimensionality: oneDimensional,
+			IntParams:    []*als.AnimationParameter{{Name: "length", Default: 10, Kind: als.IntParameter}},
+			StringParams: []*als.AnimationParameter{directionParam},
+		},
+		run: func(s *Simulator) []step {
+			length := s.intParam("length", 10)
+			if length < 1 {
+				length = 1
+			}
+			order := pixelOrder(s)
+			steps := make([]step, 0, s.numLEDs+length)
+			for head := 0; head < s.numLEDs+length-1; head++ {
+				frame := make([]int, s.numLEDs)
+				for d := 0; d < length; d++ {
+					i := head - d
+					if i < 0 || i >= s.numLEDs {
+						continue
+					}
+					p := order[i]
+					frame[p] = als.Blend(s.colors[0][p], 0, d*255/length)
+				}
+				steps = append(steps, step{colors: frame, hold: 1})
+			}
+			return steps
+		},
+	},
+	{
+		info: &als.AnimationInfo{
+			Name: "Pixel Run", Abbr: "PXR", Description: "A single pixel runs along the section",
+			RunCountDefault: -1, MinimumColors: 1, Dimensionality: oneDimensional,
+			StringParams: []*als.AnimationParameter{directionParam},
+		},
+		run: func(s *Simulator) []step {
+			steps := make([]step, 0, s.numLEDs)
+			for _, p := range pixelOrder(s) {
+				frame := make([]int, s.numLEDs)
+				frame[p] = s.colors[0][p]
+				steps = append(steps, step{colors: frame, hold: 1})
+			}
+			return steps
+		},
+	},
+	{
+		info: &als.AnimationInfo{
+			Name: "Sparkle", Abbr: "SPK", Description: "Each pixel sparkles once, in random order",
+			RunCountDefault: -1, MinimumColors: 1, Dimensionality: oneDimensional,
+		},
+		run: func(s *Simulator) []step {
+			steps := make([]step, 0, s.numLEDs)
+			for _, p := range s.rand.Perm(s.numLEDs) {
+				frame := make([]int, s.numLEDs)
+				frame[p] = s.colors[0][p]
+				steps = append(steps, step{colors: frame, hold: 1})
+			}
+			return steps
+		},
+	},
+	{
+		info: &als.AnimationInfo{
+			Name: "Stack", Abbr: "STK", Description: "Pixels run to the end of the section and stack up",
+			RunCountDefault: -1, MinimumColors: 1, Dimensionality: oneDimensional,
+			StringParams: []*als.AnimationParameter{directionParam},
+		},
+		run: func(s *Simulator) []step {
+			order := pixelOrder(s)
+			stacked := make([]int, s.numLEDs)
+			var steps []step
+			for top := s.numLEDs - 1; top >= 0; top-- {
+				for i := 0; i <= top; i++ {
+					frame := append([]int(nil), stacked...)
+					frame[order[i]] = s.colors[0][order[i]]
+					steps = append(steps, step{colors: frame, hold: 1})
+				}
+				stacked[order[top]] = s.colors[0][order[top]]
+			}
+			return steps
+		},
+	},
+	{
+		info: &als.AnimationInfo{
+			Name: "Wipe", Abbr: "WIP", Description: "Wipes the color across the section one pixel at a time",
+			RunCountDefault: -1, MinimumColors: 1, Dimensionality: oneDimensional,
+			StringParams: []*als.AnimationParameter{directionParam},
+		},
+		run: func(s *Simulator) []step {
+			frame := append([]int(nil), s.current...)
+			steps := make([]step, 0, s.numLEDs)
+			for _, p := range pixelOrder(s) {
+				frame[p] = s.colors[0][p]
+				steps = append(steps, step{colors: append([]int(nil), frame...), hold: 1})
+			}
+			return steps
+		},
+	},
+}
+
+// pixelOrder returns the section's pixel indexes in the order given by the
+// direction param
+func pixelOrder(s *Simulator) []int {
+	order := make([]int, s.numLEDs)
+	for i := range order {
+		if s.backward() {
+			order[i] = s.numLEDs - 1 - i
+		} else {
+			order[i] = i
+		}
+	}
+	return order
+}
@@ -0,0 +1,232 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+// Package simulator approximates the core AnimatedLEDStrip animations
+// locally, so lighting designs can be previewed and tested without hardware
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+)
+
+// Option configures a Simulator
+type Option func(*Simulator)
+
+// WithSeed seeds the random number generator used by random animations
+// such as Sparkle, so runs are repeatable
+func WithSeed(seed int64) Option {
+	return func(s *Simulator) {
+		s.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithTick sets the time each frame is shown for. Delays given in
+// milliseconds in the params are rounded to a whole number of ticks.
+// The default is 50ms.
+func WithTick(tick time.Duration) Option {
+	return func(s *Simulator) {
+		if tick > 0 {
+			s.tick = tick
+		}
+	}
+}
+
+// Simulator produces the frames of an animation running on a section
+type Simulator struct {
+	anim     *animation
+	params   *als.AnimationToRunParams
+	numLEDs  int
+	colors   [][]int
+	rand     *rand.Rand
+	tick     time.Duration
+	runCount int
+
+	runs     int
+	queue    []step
+	current  []int
+	holdLeft int
+}
+
+// NewSimulator creates a simulator for params running on a section of
+// numLEDs pixels. The animation is found by name or abbreviation.
+func NewSimulator(params *als.AnimationToRunParams, numLEDs int, opts ...Option) (*Simulator, error) {
+	anim := findAnimation(params.Animation)
+	if anim == nil {
+		return nil, fmt.Errorf("animation %q cannot be simulated; supported animations are %s",
+			params.Animation, strings.Join(Names(), ", "))
+	}
+	if numLEDs <= 0 {
+		return nil, fmt.Errorf("invalid number of LEDs %d", numLEDs)
+	}
+	if len(params.Colors) < anim.info.MinimumColors {
+		return nil, fmt.Errorf("%s requires at least %d colors", anim.info.Name, anim.info.MinimumColors)
+	}
+
+	s := &Simulator{
+		anim:     anim,
+		params:   params,
+		numLEDs:  numLEDs,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		tick:     50 * time.Millisecond,
+		runCount: params.RunCount,
+		current:  make([]int, numLEDs),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.runCount == 0 {
+		s.runCount = anim.info.RunCountDefault
+	}
+	for _, c := range params.Colors {
+		s.colors = append(s.colors, c.Prepare(numLEDs).Colors)
+	}
+	return s, nil
+}
+
+// Tick returns the time each frame is shown for
+func (s *Simulator) Tick() time.Duration {
+	return s.tick
+}
+
+// Done reports whether the animation has finished its run count
+func (s *Simulator) Done() bool {
+	return s.holdLeft == 0 && len(s.queue) == 0 && s.runCount > 0 && s.runs >= s.runCount
+}
+
+// Step advances one tick and returns the frame shown during it. It returns
+// false once the animation has finished, along with the last frame.
+func (s *Simulator) Step() ([]int, bool) {
+	if s.holdLeft > 0 {
+		s.holdLeft--
+		return s.frame(), true
+	}
+	if len(s.queue) == 0 {
+		if s.Done() {
+			return s.frame(), false
+		}
+		s.queue = s.anim.run(s)
+		s.runs++
+	}
+	next := s.queue[0]
+	s.queue = s.queue[1:]
+	copy(s.current, next.colors)
+	s.holdLeft = next.hold - 1
+	return s.frame(), true
+}
+
+// Frames returns the next n frames, or fewer if the animation finishes
+func (s *Simulator) Frames(n int) [][]int {
+	frames := make([][]int, 0, n)
+	for len(frames) < n {
+		frame, ok := s.Step()
+		if !ok {
+			break
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// Run calls fn with each frame in real time, one per tick, until the
+// animation finishes or ctx is done
+func (s *Simulator) Run(ctx context.Context, fn func(frame []int)) error {
+	ticker := time.NewTicker(s.tick)
+	defer ticker.Stop()
+	for {
+		frame, ok := s.Step()
+		if !ok {
+			return nil
+		}
+		fn(frame)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Simulator) frame() []int {
+	return append([]int(nil), s.current...)
+}
+
+// ticks converts a delay in milliseconds from the int param name into a
+// number of ticks, using def if the param isn't set
+func (s *Simulator) ticks(name string, def int) int {
+	ms, ok := s.params.IntParams[name]
+	if !ok {
+		ms = def
+	}
+	n := int(time.Duration(ms) * time.Millisecond / s.tick)
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+func (s *Simulator) intParam(name string, def int) int {
+	if v, ok := s.params.IntParams[name]; ok {
+		return v
+	}
+	return def
+}
+
+func (s *Simulator) backward() bool {
+	return strings.EqualFold(s.params.StringParams["direction"], "backward")
+}
+
+// Names returns the names of the animations that can be simulated
+func Names() []string {
+	names := make([]string, 0, len(animations))
+	for _, a := range animations {
+		names = append(names, a.info.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Animations returns the AnimationInfo of each animation that can be
+// simulated, e.g. to seed a fake client
+func Animations() []*als.AnimationInfo {
+	infos := make([]*als.AnimationInfo, 0, len(animations))
+	for _, name := range Names() {
+		info := *findAnimation(name).info
+		infos = append(infos, &info)
+	}
+	return infos
+}
+
+func findAnimation(name string) *animation {
+	for _, a := range animations {
+		if strings.EqualFold(a.info.Name, name) || strings.EqualFold(a.info.Abbr, name) {
+			return a
+		}
+	}
+	return nil
+}
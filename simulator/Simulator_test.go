@@ -0,0 +1,143 @@
+/*
+ *  Copyright (c) 2019-2020 AnimatedLEDStrip
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a copy
+ *  of this software and associated documentation files (the "Software"), to deal
+ *  in the Software without restriction, including without limitation the rights
+ *  to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ *  copies of the Software, and to permit persons to whom the Software is
+ *  furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included in
+ *  all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ *  AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ *  LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ *  OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ *  THE SOFTWARE.
+ */
+
+package simulator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	als "github.com/AnimatedLEDStrip/client-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const r = 0xFF0000
+
+func TestNewSimulator_Errors(t *testing.T) {
+	_, err := NewSimulator(als.NewAnimation("Ripple").Color(r).Build(), 10)
+	assert.NotNil(t, err)
+	_, err = NewSimulator(als.NewAnimation("Alternate").Color(r).Build(), 10)
+	assert.NotNil(t, err)
+	_, err = NewSimulator(als.NewAnimation("Color").Color(r).Build(), 0)
+	assert.NotNil(t, err)
+}
+
+func TestColor(t *testing.T) {
+	sim, err := NewSimulator(als.NewAnimation("col").Color(r).Build(), 3)
+	assert.Nil(t, err)
+
+	assert.Equal(t, [][]int{{r, r, r}}, sim.Frames(10))
+	assert.True(t, sim.Done())
+	frame, ok := sim.Step()
+	assert.False(t, ok)
+	assert.Equal(t, []int{r, r, r}, frame)
+}
+
+func TestWipe(t *testing.T) {
+	sim, _ := NewSimulator(als.NewAnimation("Wipe").Color(r).RunCount(1).Build(), 3)
+	assert.Equal(t, [][]int{{r, 0, 0}, {r, r, 0}, {r, r, r}}, sim.Frames(10))
+
+	sim, _ = NewSimulator(als.NewAnimation("Wipe").Color(r).RunCount(1).String("direction", "backward").Build(), 3)
+	assert.Equal(t, [][]int{{0, 0, r}, {0, r, r}, {r, r, r}}, sim.Frames(10))
+}
+
+func TestPixelRun(t *testing.T) {
+	sim, _ := NewSimulator(als.NewAnimation("Pixel Run").Color(r).RunCount(2).Build(), 2)
+	assert.Equal(t, [][]int{{r, 0}, {0, r}, {r, 0}, {0, r}}, sim.Frames(10))
+}
+
+func TestMeteor(t *testing.T) {
+	sim, _ := NewSimulator(als.NewAnimation("Meteor").Color(r).RunCount(1).Int("length", 2).Build(), 3)
+	half := als.Blend(r, 0, 127)
+	assert.Equal(t, [][]int{{r, 0, 0}, {half, r, 0}, {0, half, r}, {0, 0, half}}, sim.Frames(10))
+}
+
+func TestBounce(t *testing.T) {
+	sim, _ := NewSimulator(als.NewAnimation("Bounce").Color(r).RunCount(1).Build(), 3)
+	assert.Equal(t, [][]int{
+		{r, 0, 0}, {0, r, 0}, {0, 0, r},
+		{0, r, r}, {r, 0, r},
+		{r, r, r},
+	}, sim.Frames(20))
+}
+
+func TestStack(t *testing.T) {
+	sim, _ := NewSimulator(als.NewAnimation("Stack").Color(r).RunCount(1).Build(), 3)
+	assert.Equal(t, [][]int{
+		{r, 0, 0}, {0, r, 0}, {0, 0, r},
+		{r, 0, r}, {0, r, r},
+		{r, r, r},
+	}, sim.Frames(20))
+}
+
+func TestSparkle(t *testing.T) {
+	params := als.NewAnimation("Sparkle").Color(r).RunCount(1).Build()
+	sim1, _ := NewSimulator(params, 5, WithSeed(42))
+	sim2, _ := NewSimulator(params, 5, WithSeed(42))
+
+	frames := sim1.Frames(10)
+	assert.Equal(t, frames, sim2.Frames(10))
+	assert.Len(t, frames, 5)
+	seen := map[int]bool{}
+	for _, frame := range frames {
+		for p, c := range frame {
+			if c != 0 {
+				seen[p] = true
+			}
+		}
+	}
+	assert.Len(t, seen, 5)
+}
+
+func TestAlternate(t *testing.T) {
+	params := als.NewAnimation("Alternate").Color(r).Color(0xFF).RunCount(1).Int("delay", 100).Build()
+	sim, _ := NewSimulator(params, 2, WithTick(50*time.Millisecond))
+
+	assert.Equal(t, [][]int{{r, r}, {r, r}, {0xFF, 0xFF}, {0xFF, 0xFF}}, sim.Frames(10))
+}
+
+func TestGradientColors(t *testing.T) {
+	sim, _ := NewSimulator(als.NewAnimation("Color").Colors(als.NewColorContainer([]int{r, 0xFF})).Build(), 4)
+	frame, _ := sim.Step()
+	assert.Equal(t, als.NewColorContainer([]int{r, 0xFF}).Prepare(4).Colors, frame)
+}
+
+func TestRun(t *testing.T) {
+	sim, _ := NewSimulator(als.NewAnimation("Wipe").Color(r).RunCount(1).Build(), 3, WithTick(time.Millisecond))
+
+	var frames [][]int
+	assert.Nil(t, sim.Run(context.Background(), func(frame []int) { frames = append(frames, frame) }))
+	assert.Len(t, frames, 3)
+
+	sim, _ = NewSimulator(als.NewAnimation("Wipe").Color(r).Build(), 3, WithTick(time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, sim.Run(ctx, func([]int) {}))
+}
+
+func TestAnimations(t *testing.T) {
+	assert.Equal(t, []string{"Alternate", "Bounce", "Color", "Meteor", "Pixel Run", "Sparkle", "Stack", "Wipe"}, Names())
+	infos := Animations()
+	assert.Len(t, infos, 8)
+	assert.Equal(t, "ALT", infos[0].Abbr)
+}